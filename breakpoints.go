@@ -2,9 +2,13 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"sort"
+	"strconv"
 
 	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
 )
@@ -13,6 +17,9 @@ type frozenBreakpoint struct {
 	Bp             api.Breakpoint
 	LineInFunction int
 	LineContents   string
+	Logpoint       string          `json:",omitempty"`
+	Group          string          `json:",omitempty"`
+	GoroutineEvent *goroutineEvent `json:",omitempty"`
 }
 
 var FrozenBreakpoints []frozenBreakpoint
@@ -20,11 +27,15 @@ var DisabledBreakpoints []frozenBreakpoint
 
 // Saves position information for bp in FrozenBreakpoints
 func freezeBreakpoint(out io.Writer, bp *api.Breakpoint) {
-	if bp == nil || bp.ID < 0 || bp.FunctionName == "" || bp.File == "" {
+	if bp == nil || bp.ID < 0 || bp.FunctionName == "" || bp.File == "" || isPanicCatchpoint(bp) {
 		return
 	}
 	var fbp frozenBreakpoint
 	fbp.Bp = *bp
+	fbp.Logpoint = Logpoints[bp.ID]
+	if ev, ok := GoroutineEvents[bp.ID]; ok {
+		fbp.GoroutineEvent = &ev
+	}
 
 	locs, err := client.FindLocation(api.EvalScope{-1, 0, 0}, fbp.Bp.FunctionName, true)
 	if err != nil || len(locs) != 1 || locs[0].Function == nil || locs[0].Function.Name() != fbp.Bp.FunctionName {
@@ -76,6 +87,9 @@ func removeFrozenBreakpoint(bp *api.Breakpoint) {
 	if bp == nil {
 		return
 	}
+	delete(Logpoints, bp.ID)
+	delete(GoroutineEvents, bp.ID)
+	delete(Watchpoints, bp.ID)
 	for i := range FrozenBreakpoints {
 		if FrozenBreakpoints[i].Bp.ID == bp.ID {
 			copy(FrozenBreakpoints[i:], FrozenBreakpoints[i+1:])
@@ -104,9 +118,14 @@ func clearFrozenBreakpoints() {
 }
 
 func restoreFrozenBreakpoints(out io.Writer) {
-	// Restore frozen breakpoints
+	// Restore frozen breakpoints, groups are keyed by the ID of the new
+	// breakpoint
+	groups := make(map[int]string)
 	for i := range FrozenBreakpoints {
-		FrozenBreakpoints[i].Restore(out, true)
+		bp := FrozenBreakpoints[i].Restore(out, true)
+		if bp != nil && FrozenBreakpoints[i].Group != "" {
+			groups[bp.ID] = FrozenBreakpoints[i].Group
+		}
 	}
 	for i := range DisabledBreakpoints {
 		DisabledBreakpoints[i].Restore(out, false)
@@ -123,28 +142,35 @@ func restoreFrozenBreakpoints(out io.Writer) {
 			freezeBreakpoint(out, bp)
 		}
 	}
+	for i := range FrozenBreakpoints {
+		FrozenBreakpoints[i].Group = groups[FrozenBreakpoints[i].Bp.ID]
+	}
 }
 
-func (fbp *frozenBreakpoint) Restore(out io.Writer, create bool) {
+// Restore relocates fbp to the current version of its function and, if
+// create is set, creates it. Returns the created breakpoint.
+func (fbp *frozenBreakpoint) Restore(out io.Writer, create bool) *api.Breakpoint {
 	if fbp.Bp.FunctionName == "" || fbp.Bp.File == "" {
-		return
+		return nil
 	}
 
 	if fbp.LineInFunction == 0 {
 		fbp.Bp.Addr = 0
 		fbp.Bp.File = ""
 		fbp.Bp.Line = -1
-		_, err := client.CreateBreakpoint(&fbp.Bp)
+		bp, err := client.CreateBreakpoint(&fbp.Bp)
 		if err != nil {
 			fmt.Fprintf(out, "Could not restore breakpoint at function %s: %v\n", fbp.Bp.FunctionName, err)
+			return nil
 		}
-		return
+		fbp.restoreEvents(bp.ID)
+		return bp
 	}
 
 	locs, err := client.FindLocation(api.EvalScope{-1, 0, 0}, fbp.Bp.FunctionName, true)
 	if err != nil || len(locs) != 1 || locs[0].Function == nil || locs[0].Function.Name() != fbp.Bp.FunctionName {
 		fmt.Fprintf(out, "Could not restore breakpoint %d, function not found\n", fbp.Bp.ID)
-		return
+		return nil
 	}
 	functionLoc := locs[0]
 
@@ -153,7 +179,7 @@ func (fbp *frozenBreakpoint) Restore(out io.Writer, create bool) {
 
 	fh, err := os.Open(functionLoc.File)
 	if err != nil {
-		return
+		return nil
 	}
 	defer fh.Close()
 
@@ -188,30 +214,52 @@ func (fbp *frozenBreakpoint) Restore(out io.Writer, create bool) {
 	fbp.Bp.Line = bestMatch
 
 	if create {
-		fbp.Set(out, &functionLoc)
+		return fbp.Set(out, &functionLoc)
 	}
+	return nil
 }
 
-func (fbp *frozenBreakpoint) Set(out io.Writer, functionLoc *api.Location) {
+func (fbp *frozenBreakpoint) Set(out io.Writer, functionLoc *api.Location) *api.Breakpoint {
 	bp, err := client.CreateBreakpoint(&fbp.Bp)
 	if err != nil {
 		fmt.Fprintf(out, "Could not restore breakpoint at %s:%d: %v\n", fbp.Bp.File, fbp.Bp.Line, err)
-		return
+		return nil
 	}
 
 	fbp.Bp = *bp
+	fbp.restoreEvents(bp.ID)
 
 	if functionLoc != nil {
 		if bp.FunctionName != functionLoc.Function.Name() {
 			client.ClearBreakpoint(bp.ID)
 			fmt.Fprintf(out, "Could not restore breakpoint %d (function name mismatch)\n", fbp.Bp.ID)
+			return nil
 		}
 	}
+	return bp
+}
+
+// restoreEvents records the logpoint format and goroutine event of fbp for
+// the breakpoint with the specified ID.
+func (fbp *frozenBreakpoint) restoreEvents(id int) {
+	if fbp.Logpoint != "" {
+		Logpoints[id] = fbp.Logpoint
+	}
+	if fbp.GoroutineEvent != nil {
+		GoroutineEvents[id] = *fbp.GoroutineEvent
+	}
 }
 
 func disableBreakpoint(bp *api.Breakpoint) {
+	disableFrozenBreakpoint(bp.ID)
+	saveConfiguration()
+	refreshState(refreshToSameFrame, clearBreakpoint, nil)
+	wnd.Changed()
+}
+
+func disableFrozenBreakpoint(id int) {
 	for i := range FrozenBreakpoints {
-		if FrozenBreakpoints[i].Bp.ID == bp.ID {
+		if FrozenBreakpoints[i].Bp.ID == id {
 			client.ClearBreakpoint(FrozenBreakpoints[i].Bp.ID)
 			FrozenBreakpoints[i].Bp.ID += 1000000 // XXX ugly hack!
 			DisabledBreakpoints = append(DisabledBreakpoints, FrozenBreakpoints[i])
@@ -220,14 +268,18 @@ func disableBreakpoint(bp *api.Breakpoint) {
 			break
 		}
 	}
+}
+
+func enableBreakpoint(bp *api.Breakpoint) {
+	enableFrozenBreakpoint(bp.ID)
 	saveConfiguration()
 	refreshState(refreshToSameFrame, clearBreakpoint, nil)
 	wnd.Changed()
 }
 
-func enableBreakpoint(bp *api.Breakpoint) {
+func enableFrozenBreakpoint(id int) {
 	for i := range DisabledBreakpoints {
-		if DisabledBreakpoints[i].Bp.ID == bp.ID {
+		if DisabledBreakpoints[i].Bp.ID == id {
 			FrozenBreakpoints = append(FrozenBreakpoints, DisabledBreakpoints[i])
 			fbp := &FrozenBreakpoints[len(FrozenBreakpoints)-1]
 			copy(DisabledBreakpoints[i:], DisabledBreakpoints[i+1:])
@@ -236,12 +288,258 @@ func enableBreakpoint(bp *api.Breakpoint) {
 			break
 		}
 	}
-	saveConfiguration()
-	refreshState(refreshToSameFrame, clearBreakpoint, nil)
-	wnd.Changed()
 }
 
 type anyBreakpoint struct {
 	*api.Breakpoint
 	enabled bool
 }
+
+// findFrozenBreakpoint returns the enabled or disabled breakpoint with the
+// specified ID or name.
+func findFrozenBreakpoint(ref string) *frozenBreakpoint {
+	id, err := strconv.Atoi(ref)
+	for _, fbps := range [][]frozenBreakpoint{FrozenBreakpoints, DisabledBreakpoints} {
+		for i := range fbps {
+			if (err == nil && fbps[i].Bp.ID == id) || (err != nil && fbps[i].Bp.Name == ref) {
+				return &fbps[i]
+			}
+		}
+	}
+	return nil
+}
+
+// breakpointGroup returns the group of the breakpoint with the specified ID.
+func breakpointGroup(id int) string {
+	for _, fbps := range [][]frozenBreakpoint{FrozenBreakpoints, DisabledBreakpoints} {
+		for i := range fbps {
+			if fbps[i].Bp.ID == id {
+				return fbps[i].Group
+			}
+		}
+	}
+	return ""
+}
+
+func setBreakpointGroup(id int, group string) bool {
+	for _, fbps := range [][]frozenBreakpoint{FrozenBreakpoints, DisabledBreakpoints} {
+		for i := range fbps {
+			if fbps[i].Bp.ID == id {
+				fbps[i].Group = group
+				saveConfiguration()
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// breakpointGroups returns the names of all breakpoint groups, sorted.
+func breakpointGroups() []string {
+	seen := make(map[string]bool)
+	r := []string{}
+	for _, fbps := range [][]frozenBreakpoint{FrozenBreakpoints, DisabledBreakpoints} {
+		for i := range fbps {
+			if g := fbps[i].Group; g != "" && !seen[g] {
+				seen[g] = true
+				r = append(r, g)
+			}
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+// setBreakpointGroupEnabled enables or disables all breakpoints in group,
+// returns the number of breakpoints changed.
+func setBreakpointGroupEnabled(group string, enable bool) int {
+	fbps := FrozenBreakpoints
+	if enable {
+		fbps = DisabledBreakpoints
+	}
+	ids := []int{}
+	for i := range fbps {
+		if fbps[i].Group == group {
+			ids = append(ids, fbps[i].Bp.ID)
+		}
+	}
+	for _, id := range ids {
+		if enable {
+			enableFrozenBreakpoint(id)
+		} else {
+			disableFrozenBreakpoint(id)
+		}
+	}
+	saveConfiguration()
+	refreshState(refreshToSameFrame, clearBreakpoint, nil)
+	wnd.Changed()
+	return len(ids)
+}
+
+// portableBreakpoint is the representation of a breakpoint used by 'break
+// save' and 'break load', its location is relative to the start of its
+// function so that it can be shared between machines and versions of the
+// program.
+type portableBreakpoint struct {
+	Name           string          `json:"name,omitempty"`
+	Function       string          `json:"function"`
+	LineOffset     int             `json:"lineOffset"`
+	LineContents   string          `json:"lineContents,omitempty"`
+	File           string          `json:"file,omitempty"`
+	Cond           string          `json:"cond,omitempty"`
+	HitCond        string          `json:"hitCond,omitempty"`
+	HitCondPerG    bool            `json:"hitCondPerG,omitempty"`
+	Tracepoint     bool            `json:"tracepoint,omitempty"`
+	TraceReturn    bool            `json:"traceReturn,omitempty"`
+	Goroutine      bool            `json:"goroutine,omitempty"`
+	Stacktrace     int             `json:"stacktrace,omitempty"`
+	Variables      []string        `json:"variables,omitempty"`
+	LoadArgs       *api.LoadConfig `json:"loadArgs,omitempty"`
+	LoadLocals     *api.LoadConfig `json:"loadLocals,omitempty"`
+	Logpoint       string          `json:"logpoint,omitempty"`
+	Group          string          `json:"group,omitempty"`
+	GoroutineEvent *goroutineEvent `json:"goroutineEvent,omitempty"`
+	Disabled       bool            `json:"disabled,omitempty"`
+}
+
+func (fbp *frozenBreakpoint) portable(disabled bool) portableBreakpoint {
+	return portableBreakpoint{
+		Name:           fbp.Bp.Name,
+		Function:       fbp.Bp.FunctionName,
+		LineOffset:     fbp.LineInFunction,
+		LineContents:   fbp.LineContents,
+		File:           fbp.Bp.File,
+		Cond:           fbp.Bp.Cond,
+		HitCond:        fbp.Bp.HitCond,
+		HitCondPerG:    fbp.Bp.HitCondPerG,
+		Tracepoint:     fbp.Bp.Tracepoint,
+		TraceReturn:    fbp.Bp.TraceReturn,
+		Goroutine:      fbp.Bp.Goroutine,
+		Stacktrace:     fbp.Bp.Stacktrace,
+		Variables:      fbp.Bp.Variables,
+		LoadArgs:       fbp.Bp.LoadArgs,
+		LoadLocals:     fbp.Bp.LoadLocals,
+		Logpoint:       fbp.Logpoint,
+		Group:          fbp.Group,
+		GoroutineEvent: fbp.GoroutineEvent,
+		Disabled:       disabled,
+	}
+}
+
+func (pbp *portableBreakpoint) frozen() frozenBreakpoint {
+	file := pbp.File
+	if file == "" {
+		// Restore only needs to know that the breakpoint had a file, the
+		// actual one is looked up from the function.
+		file = "?"
+	}
+	return frozenBreakpoint{
+		Bp: api.Breakpoint{
+			Name:         pbp.Name,
+			FunctionName: pbp.Function,
+			File:         file,
+			Cond:         pbp.Cond,
+			HitCond:      pbp.HitCond,
+			HitCondPerG:  pbp.HitCondPerG,
+			Tracepoint:   pbp.Tracepoint,
+			TraceReturn:  pbp.TraceReturn,
+			Goroutine:    pbp.Goroutine,
+			Stacktrace:   pbp.Stacktrace,
+			Variables:    pbp.Variables,
+			LoadArgs:     pbp.LoadArgs,
+			LoadLocals:   pbp.LoadLocals,
+		},
+		LineInFunction: pbp.LineOffset,
+		LineContents:   pbp.LineContents,
+		Logpoint:       pbp.Logpoint,
+		Group:          pbp.Group,
+		GoroutineEvent: pbp.GoroutineEvent,
+	}
+}
+
+// exportBreakpoints writes all enabled and disabled breakpoints to path.
+func exportBreakpoints(path string) (int, error) {
+	updateFrozenBreakpoints()
+	pbps := []portableBreakpoint{}
+	for i := range FrozenBreakpoints {
+		pbps = append(pbps, FrozenBreakpoints[i].portable(false))
+	}
+	for i := range DisabledBreakpoints {
+		pbps = append(pbps, DisabledBreakpoints[i].portable(true))
+	}
+
+	fh, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer fh.Close()
+	enc := json.NewEncoder(fh)
+	enc.SetIndent("", "\t")
+	return len(pbps), enc.Encode(pbps)
+}
+
+// importBreakpoints creates the breakpoints saved in path, relocating them
+// the same way breakpoints are relocated on restart.
+func importBreakpoints(out io.Writer, path string) (int, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var pbps []portableBreakpoint
+	if err := json.Unmarshal(buf, &pbps); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for i := range pbps {
+		if importPortableBreakpoint(out, &pbps[i]) {
+			n++
+		}
+	}
+	saveConfiguration()
+	refreshState(refreshToSameFrame, clearBreakpoint, nil)
+	return n, nil
+}
+
+// importPortableBreakpoint creates pbp, returns false if it could not be
+// created.
+func importPortableBreakpoint(out io.Writer, pbp *portableBreakpoint) bool {
+	if pbp.Function == "" {
+		fmt.Fprintf(out, "Skipping breakpoint without a function\n")
+		return false
+	}
+	fbp := pbp.frozen()
+	bp := fbp.Restore(out, true)
+	if bp == nil {
+		return false
+	}
+	freezeBreakpoint(out, bp)
+	setBreakpointGroup(bp.ID, pbp.Group)
+	if pbp.Disabled {
+		disableFrozenBreakpoint(bp.ID)
+	}
+	return true
+}
+
+// clearAllBreakpoints clears all user breakpoints, enabled or disabled.
+func clearAllBreakpoints(out io.Writer) {
+	bps, err := client.ListBreakpoints()
+	if err != nil {
+		fmt.Fprintf(out, "Could not list breakpoints: %v\n", err)
+	}
+	for _, bp := range bps {
+		if bp.ID < 0 || isPanicCatchpoint(bp) {
+			continue
+		}
+		_, err := client.ClearBreakpoint(bp.ID)
+		if err != nil {
+			fmt.Fprintf(out, "Could not clear breakpoint %d: %v\n", bp.ID, err)
+		}
+	}
+	FrozenBreakpoints = nil
+	DisabledBreakpoints = nil
+	Logpoints = map[int]string{}
+	GoroutineEvents = map[int]goroutineEvent{}
+	Watchpoints = map[int]*watchpoint{}
+	saveConfiguration()
+}
@@ -13,7 +13,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"text/tabwriter"
+	"time"
 	"unicode"
 
 	"golang.org/x/mobile/event/key"
@@ -97,6 +99,9 @@ var historySearch bool
 var historyNeedle string
 var cmds *Commands
 
+// Maximum number of commands saved in the history of each debug target.
+const maxCmdHistory = 1000
+
 func DebugCommands() *Commands {
 	c := &Commands{}
 
@@ -110,13 +115,42 @@ Type "help" followed by the name of a command for more information about it.`},
 
 	break [name] <linespec>
 	break
+	break group
+	break group add <group> <breakpoint name or id>...
+	break group remove <breakpoint name or id>...
+	break group enable <group>
+	break group disable <group>
+	break save <file>
+	break load <file>
+	break -go-create [fnregex]
+	break -go-exit [fnregex]
 
 See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/locspec.md for the syntax of linespec. To set breakpoints you can also right click on a source line and click "Set breakpoint". Breakpoint properties can be changed by right clicking on a breakpoint (either in the source panel or the breakpoints panel) and selecting "Edit breakpoint".
 
-Without arguments displays all currently set breakponts.`},
+Without arguments displays all currently set breakponts.
+
+The 'group' subcommands manage breakpoint groups: 'break group' lists all groups, 'add' and 'remove' change the group of the specified breakpoints and 'enable' and 'disable' toggle all breakpoints in a group at once.
+
+'break save' writes all breakpoints to a JSON file, with their location expressed as a function name and a line offset, so that it can be shared or checked into a repository. 'break load' creates the breakpoints contained in such a file. The rest of the line after 'save' or 'load' is the path of the file.
+
+The names 'group', 'save' and 'load' are reserved for these subcommands and can not be used as breakpoint names.
+
+'break -go-create' stops every time a goroutine is created and 'break -go-exit' every time a goroutine exits. If fnregex is specified only goroutines whose creating function or start function match it will stop the target. Use the breakpoint editor to change them into tracepoints.`},
 		{aliases: []string{"clear"}, group: breakCmds, cmdFn: clear, helpMsg: `Deletes breakpoint.
 		
 			clear <breakpoint name or id>`},
+		{aliases: []string{"watch"}, group: breakCmds, cmdFn: watch, helpMsg: `Sets a watchpoint.
+
+	watch <expr>
+
+Stops the program when the memory backing expr is written and shows its old and new value. Watchpoints can also be set by right clicking on a variable and selecting "Watch for writes".
+
+If the backend does not support hardware watchpoints the current thread is single stepped until the value of expr changes, writes made by other threads will not be detected.`},
+		{aliases: []string{"rev-watch"}, group: breakCmds, cmdFn: revWatch, helpMsg: `Finds the last write to an expression (recordings only).
+
+	rev-watch <expr>
+
+Runs the recording backwards until the value of expr is different from its current value and stops at the instruction that wrote it. This can also be done by right clicking on a variable and selecting "Find last write".`},
 		{aliases: []string{"restart", "r"}, group: runCmds, cmdFn: restart, helpMsg: `Restart process.
 
 For live processes any argument passed to restart will be used as argument for the program. 
@@ -180,7 +214,13 @@ Type 'help scope-expr' for a description of <scope-expr>.`},
 		
 			list <linespec>
 		
-		See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions.`},
+		See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions.
+
+			list /regex/
+
+		Moves to the next line of the Listing panel matching regex (or the next instruction of the Disassembly panel, if there is no source code).
+
+		In the Listing and Disassembly panels use Ctrl+F to search and, in the Listing panel, Ctrl+G to go to a line.`},
 		{aliases: []string{"set"}, group: dataCmds, cmdFn: setVar, complete: completeVariable, helpMsg: `Changes the value of a variable.
 
 	set <variable> = <value>
@@ -210,6 +250,35 @@ Saves the current layout. Overwrite "default" to change the default layout.
 	layout list
 	
 Lists saved layouts.`},
+		{aliases: []string{"history"}, group: otherCmds, cmdFn: historyCommand, helpMsg: `Lists or re-runs commands from the history.
+
+	history
+	history <substring>
+
+Lists the commands in the history, optionally only the ones containing substring. The history is saved across sessions for each debug target.
+
+	history <n>
+
+Runs the command number n of the history.`},
+		{aliases: []string{"session"}, group: winCmds, cmdFn: sessionCommand, helpMsg: `Manages saved debugging sessions.
+
+	session save <name>
+
+Saves breakpoints, watched expressions, custom formatters, window layout, detail windows and the command line.
+
+	session load <name>
+
+Restores the breakpoints, expressions, formatters and windows of a saved session. To also restore the command line start gdlv with:
+
+	gdlv -session <name>
+
+	session list
+
+Lists saved sessions.
+
+	session delete <name>
+
+Deletes a saved session.`},
 		{aliases: []string{"config"}, cmdFn: configCommand, helpMsg: `Configuration
 
 	config
@@ -225,14 +294,30 @@ With the 'zoom' subcommand changes the display scaling factor (makes fonts large
 	scroll clear		Clears scrollback
 	scroll silence		Silences output from inferior
 	scroll noise		Re-enables output from inferior.
+	scroll save <file>	Saves the scrollback to file, as HTML if the name of the file ends in .html.
+
+Use Ctrl+F to search the scrollback.
 `},
 		{aliases: []string{"exit", "quit", "q"}, cmdFn: exitCommand, helpMsg: "Exit the debugger."},
 
+		{aliases: []string{"tracelog"}, group: breakCmds, cmdFn: tracelogCommand, helpMsg: `Manages the log of tracepoint hits.
+
+	tracelog
+	tracelog clear
+	tracelog export <file>
+
+Without arguments opens the trace window.
+With the 'clear' subcommand deletes all recorded tracepoint hits.
+With the 'export' subcommand writes the tracepoint hits currently shown in the trace window to <file>, as JSON if the file name ends in .json and as CSV otherwise.
+`},
 		{aliases: []string{"window", "win"}, complete: completeWindow, cmdFn: windowCommand, helpMsg: `Opens a window.
 	
 	window <kind>
+	window memory <expr>
 	
-Kind is one of listing, diassembly, goroutines, stacktrace, variables, globals, breakpoints, threads, registers, sources, functions, types and checkpoints.
+Kind is one of listing, diassembly, goroutines, stacktrace, variables, globals, breakpoints, threads, registers, sources, functions, types, checkpoints, memory, libraries, goroutinetree, trace and timeline.
+
+The second form opens the memory window examining the memory pointed to by <expr>, which can be either a numeric address or an expression.
 
 Shortcuts:
 	Alt-1	Listing window
@@ -358,6 +443,10 @@ and GDLV_BOLD_FONT to the path of two ttf files.
 		fmt.Fprintln(w, "    F10, Alt-right \t Next")
 		fmt.Fprintln(w, "    F11, Alt-down \t Step")
 		fmt.Fprintln(w, "    Shift-F11, Alt-up \t Step Out")
+		fmt.Fprintln(w, "    Alt-left \t Navigate back in the Listing window")
+		fmt.Fprintln(w, "    Alt-Shift-right \t Navigate forward in the Listing window")
+		fmt.Fprintln(w, "    Ctrl-F \t Find (in the Command, Listing and Disassembly windows)")
+		fmt.Fprintln(w, "    Ctrl-G \t Go to line (in the Listing window)")
 		if err := w.Flush(); err != nil {
 			return err
 		}
@@ -385,6 +474,9 @@ func setBreakpoint(out io.Writer, tracepoint bool, argstr string) error {
 	}
 
 	defer refreshState(refreshToSameFrame, clearBreakpoint, nil)
+	if strings.HasPrefix(argstr, "-go-") {
+		return setGoroutineEventBreakpoint(out, tracepoint, argstr)
+	}
 	args := strings.SplitN(argstr, " ", 2)
 
 	requestedBp := &api.Breakpoint{}
@@ -393,6 +485,9 @@ func setBreakpoint(out io.Writer, tracepoint bool, argstr string) error {
 	case 1:
 		locspec = argstr
 	case 2:
+		if breakpointSubcommands[args[0]] {
+			return fmt.Errorf("%q can not be used as a breakpoint name", args[0])
+		}
 		if api.ValidBreakpointName(args[0]) == nil {
 			requestedBp.Name = args[0]
 			locspec = args[1]
@@ -425,25 +520,26 @@ func setBreakpoint(out io.Writer, tracepoint bool, argstr string) error {
 	return nil
 }
 
-func setBreakpointEx(out io.Writer, requestedBp *api.Breakpoint) {
+func setBreakpointEx(out io.Writer, requestedBp *api.Breakpoint) *api.Breakpoint {
 	if curThread < 0 {
 		switch {
 		default:
 			fallthrough
 		case requestedBp.Addr != 0:
 			fmt.Fprintf(out, "error: process exited\n")
-			return
+			return nil
 		case requestedBp.FunctionName != "":
 			ScheduledBreakpoints = append(ScheduledBreakpoints, fmt.Sprintf("B%s", requestedBp.FunctionName))
 		case requestedBp.File != "":
 			ScheduledBreakpoints = append(ScheduledBreakpoints, fmt.Sprintf("T%s:%d", requestedBp.File, requestedBp.Line))
 		}
 		fmt.Fprintf(out, "Breakpoint will be set on restart\n")
-		return
+		return nil
 	}
 	bp, err := client.CreateBreakpoint(requestedBp)
 	if err != nil {
 		fmt.Fprintf(out, "Could not create breakpoint: %v\n", err)
+		return nil
 	}
 
 	fmt.Fprintf(out, "%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
@@ -454,13 +550,14 @@ func setBreakpointEx(out io.Writer, requestedBp *api.Breakpoint) {
 		}
 	}
 	freezeBreakpoint(out, bp)
+	return bp
 }
 
 func listBreakpoints() {
 	wnd.Lock()
 	defer wnd.Unlock()
 	style := wnd.Style()
-	c := scrollbackEditor.Append(true)
+	c := scrollbackAppend()
 	defer c.End()
 	bps, err := client.ListBreakpoints()
 	if err != nil {
@@ -482,10 +579,97 @@ func listBreakpoints() {
 	}
 }
 
+// breakpointSubcommands are the subcommands of break, they can not be used
+// as breakpoint names.
+var breakpointSubcommands = map[string]bool{"save": true, "load": true, "group": true}
+
 func breakpoint(out io.Writer, args string) error {
+	argv := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	switch argv[0] {
+	case "save", "load":
+		if len(argv) < 2 || strings.TrimSpace(argv[1]) == "" {
+			return fmt.Errorf("not enough arguments")
+		}
+		path := expandTilde(strings.TrimSpace(argv[1]))
+		if argv[0] == "save" {
+			n, err := exportBreakpoints(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Saved %d breakpoints to %s\n", n, path)
+			return nil
+		}
+		n, err := importBreakpoints(out, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Loaded %d breakpoints from %s\n", n, path)
+		return nil
+	case "group":
+		return breakpointGroupCommand(out, strings.Fields(args)[1:])
+	}
 	return setBreakpoint(out, false, args)
 }
 
+func breakpointGroupCommand(out io.Writer, argv []string) error {
+	if len(argv) == 0 {
+		for _, group := range breakpointGroups() {
+			enabled, disabled := 0, 0
+			for i := range FrozenBreakpoints {
+				if FrozenBreakpoints[i].Group == group {
+					enabled++
+				}
+			}
+			for i := range DisabledBreakpoints {
+				if DisabledBreakpoints[i].Group == group {
+					disabled++
+				}
+			}
+			fmt.Fprintf(out, "%s: %d enabled, %d disabled\n", group, enabled, disabled)
+		}
+		return nil
+	}
+
+	switch argv[0] {
+	case "add":
+		if len(argv) < 3 {
+			return fmt.Errorf("not enough arguments")
+		}
+		for _, ref := range argv[2:] {
+			fbp := findFrozenBreakpoint(ref)
+			if fbp == nil {
+				return fmt.Errorf("could not find breakpoint %q", ref)
+			}
+			setBreakpointGroup(fbp.Bp.ID, argv[1])
+		}
+	case "remove":
+		if len(argv) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+		for _, ref := range argv[1:] {
+			fbp := findFrozenBreakpoint(ref)
+			if fbp == nil {
+				return fmt.Errorf("could not find breakpoint %q", ref)
+			}
+			setBreakpointGroup(fbp.Bp.ID, "")
+		}
+	case "enable", "disable":
+		if len(argv) != 2 {
+			return fmt.Errorf("wrong number of arguments")
+		}
+		n := setBreakpointGroupEnabled(argv[1], argv[0] == "enable")
+		fmt.Fprintf(out, "%d breakpoints %sd\n", n, argv[0])
+		return nil
+	default:
+		return fmt.Errorf("unknown subcommand %q", argv[0])
+	}
+	wnd.Lock()
+	breakpointsPanel.id++
+	wnd.Unlock()
+	wnd.Changed()
+	return nil
+}
+
 func clear(out io.Writer, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -748,28 +932,38 @@ func parseOneRedirect(w []string, redirs *[3]string) ([]string, bool, error) {
 }
 
 func cont(out io.Writer, args string) error {
-	stateChan := client.Continue()
 	var state *api.DebuggerState
-	for state = range stateChan {
-		if state.Err != nil {
-			refreshState(refreshToFrameZero, clearStop, state)
-			return state.Err
+	for {
+		stateChan := client.Continue()
+		for state = range stateChan {
+			if state.Err != nil {
+				refreshState(refreshToFrameZero, clearStop, state)
+				return state.Err
+			}
+			printcontext(out, state)
+		}
+		if !skipGoroutineEventStop(state) {
+			break
 		}
-		printcontext(out, state)
 	}
 	refreshState(refreshToFrameZero, clearStop, state)
 	return nil
 }
 
 func rewind(out io.Writer, args string) error {
-	stateChan := client.Rewind()
 	var state *api.DebuggerState
-	for state = range stateChan {
-		if state.Err != nil {
-			refreshState(refreshToFrameZero, clearStop, state)
-			return state.Err
+	for {
+		stateChan := client.Rewind()
+		for state = range stateChan {
+			if state.Err != nil {
+				refreshState(refreshToFrameZero, clearStop, state)
+				return state.Err
+			}
+			printcontext(out, state)
+		}
+		if !skipGoroutineEventStop(state) {
+			break
 		}
-		printcontext(out, state)
 	}
 	refreshState(refreshToFrameZero, clearStop, state)
 	return nil
@@ -833,6 +1027,9 @@ continueLoop:
 				}
 			}
 		}
+		if state.NextInProgress && skipGoroutineEventStop(state) {
+			continue
+		}
 		if !state.NextInProgress || conf.StopOnNextBreakpoint {
 			break continueLoop
 		}
@@ -1050,6 +1247,7 @@ func interrupt(out io.Writer, args string) error {
 		return nil
 	}
 	StarlarkEnv.Cancel()
+	atomic.StoreInt32(&watchStopRequested, 1)
 	state, err := client.GetStateNonBlocking()
 	if err == nil && state.Recording {
 		return client.StopRecording()
@@ -1094,6 +1292,14 @@ func detailsVar(out io.Writer, args string) error {
 }
 
 func listCommand(out io.Writer, args string) error {
+	rx, err := parseListRegex(args)
+	if err != nil {
+		return err
+	}
+	if rx != nil {
+		return listSearch(out, rx)
+	}
+
 	locs, err := client.FindLocation(currentEvalScope(), args, false)
 	if err != nil {
 		return err
@@ -1107,7 +1313,10 @@ func listCommand(out io.Writer, args string) error {
 		return errors.New("can not list multiple locations")
 	}
 
+	wnd.Lock()
+	listingPushHistory()
 	listingPanel.pinnedLoc = &locs[0]
+	wnd.Unlock()
 	refreshState(refreshToSameFrame, clearNothing, nil)
 
 	return nil
@@ -1258,6 +1467,8 @@ type configWindow struct {
 	selectedSubstitutionRule int
 	from                     nucular.TextEditor
 	to                       nucular.TextEditor
+	syntaxTheme              string // theme whose colors are in syntaxColors
+	syntaxColors             [numSyntaxClasses]nucular.TextEditor
 }
 
 func newConfigWindow() *configWindow {
@@ -1318,6 +1529,12 @@ func (cw *configWindow) Update(w *nucular.Window) {
 		}
 	}
 
+	w.Row(20).Static(col1, 200)
+	w.Spacing(1)
+	if w.CheckboxText("Stop on panic", &conf.StopOnPanic) {
+		go applyStopOnPanic(&editorWriter{true})
+	}
+
 	w.Row(20).Static()
 	w.LayoutFitWidth(0, 100)
 	w.Label("Default step behavior:", "LC")
@@ -1382,6 +1599,12 @@ func (cw *configWindow) Update(w *nucular.Window) {
 		w.TreePop()
 	}
 
+	w.Row(30).Static(0)
+	if w.TreePush(nucular.TreeTab, "Syntax colors:", false) {
+		cw.syntaxColorsEditor(w)
+		w.TreePop()
+	}
+
 	w.Row(20).Static(0, 100)
 	w.Spacing(1)
 	if w.ButtonText("OK") {
@@ -1390,6 +1613,43 @@ func (cw *configWindow) Update(w *nucular.Window) {
 	}
 }
 
+// syntaxColorsEditor edits the syntax highlighting colors of the current
+// theme.
+func (cw *configWindow) syntaxColorsEditor(w *nucular.Window) {
+	sc := conf.SyntaxColors[conf.Theme]
+	if sc == nil {
+		sc = &SyntaxColors{}
+		conf.SyntaxColors[conf.Theme] = sc
+	}
+	if cw.syntaxTheme != conf.Theme {
+		cw.syntaxTheme = conf.Theme
+		for class := syntaxKeyword; class < numSyntaxClasses; class++ {
+			cw.syntaxColors[class].Flags = nucular.EditSelectable | nucular.EditClipboard
+			cw.syntaxColors[class].Buffer = []rune(*sc.field(class))
+		}
+	}
+	for class := syntaxKeyword; class < numSyntaxClasses; class++ {
+		w.Row(20).Static(100, 100, 40)
+		w.Label(syntaxClassNames[class]+":", "LC")
+		cw.syntaxColors[class].Edit(w)
+		if v := string(cw.syntaxColors[class].Buffer); v != *sc.field(class) {
+			*sc.field(class) = v
+			setupSyntaxColors()
+		}
+		if c, err := parseColor(*sc.field(class)); err == nil {
+			w.LabelColored("abc", "LC", c)
+		} else {
+			w.Spacing(1)
+		}
+	}
+	w.Row(20).Static(200)
+	if w.ButtonText("Reset to default") {
+		*sc = defaultSyntaxColors[conf.Theme]
+		cw.syntaxTheme = ""
+		setupSyntaxColors()
+	}
+}
+
 func stringCombo(w *nucular.Window, values []string, value *string) {
 	i0 := 0
 	for i := range values {
@@ -1406,6 +1666,14 @@ func stringCombo(w *nucular.Window, values []string, value *string) {
 }
 
 func scrollCommand(out io.Writer, args string) error {
+	if strings.HasPrefix(args, "save ") {
+		path := strings.TrimSpace(args[len("save "):])
+		if err := saveScrollback(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Scrollback saved to %s\n", path)
+		return nil
+	}
 	switch args {
 	case "clear":
 		wnd.Lock()
@@ -1435,7 +1703,12 @@ func scrollCommand(out io.Writer, args string) error {
 }
 
 func windowCommand(out io.Writer, args string) error {
-	args = strings.ToLower(strings.TrimSpace(args))
+	args = strings.TrimSpace(args)
+	if strings.HasPrefix(strings.ToLower(args), "memory ") {
+		openMemoryWindow(strings.TrimSpace(args[len("memory "):]))
+		return nil
+	}
+	args = strings.ToLower(args)
 	if args == "styled" {
 		styled.EditStyle(wnd, nucular.WindowNonmodal|nucular.WindowClosable, func(out string) {
 			fh, err := os.Create("boring-style.go")
@@ -1557,7 +1830,7 @@ func goroutinesCommand(out io.Writer, args string) error {
 	wnd.Lock()
 	defer wnd.Unlock()
 	style := wnd.Style()
-	c := scrollbackEditor.Append(true)
+	c := scrollbackAppend()
 	defer c.End()
 
 	lim := goroutinesPanel.limit
@@ -1636,7 +1909,7 @@ func printcontext(out io.Writer, state *api.DebuggerState) error {
 		if (state.CurrentThread != nil) && (state.Threads[i].ID == state.CurrentThread.ID) {
 			continue
 		}
-		if state.Threads[i].Breakpoint != nil {
+		if state.Threads[i].Breakpoint != nil && !threadFiltered(state, state.Threads[i]) {
 			printcontextThread(state.Threads[i])
 		}
 	}
@@ -1650,12 +1923,16 @@ func printcontext(out io.Writer, state *api.DebuggerState) error {
 		return nil
 	}
 
-	printcontextThread(state.CurrentThread)
+	if !threadFiltered(state, state.CurrentThread) {
+		printcontextThread(state.CurrentThread)
+		printPanicInfo(state.CurrentThread)
+		printWatchpointHit(out, state.CurrentThread)
+	}
 
 	return nil
 }
 
-func printReturnValues(c *richtext.Ctor, th *api.Thread) {
+func printReturnValues(c scrollbackCtor, th *api.Thread) {
 	if len(th.ReturnValues) == 0 {
 		return
 	}
@@ -1669,8 +1946,26 @@ func printReturnValues(c *richtext.Ctor, th *api.Thread) {
 func printcontextThread(th *api.Thread) {
 	wnd.Lock()
 	defer wnd.Unlock()
+
+	if th.Breakpoint != nil && th.Breakpoint.Tracepoint {
+		recordTracepointHit(th)
+		if !tracePanel.echo {
+			return
+		}
+		if isRecoveredTracepoint(th.Breakpoint) {
+			c := scrollbackAppend()
+			c.Text(fmt.Sprintf("> goroutine %d recovered from a panic\n", th.GoroutineID))
+			c.End()
+			return
+		}
+		if format, ok := Logpoints[th.Breakpoint.ID]; ok {
+			printLogpoint(th, format)
+			return
+		}
+	}
+
 	style := wnd.Style()
-	c := scrollbackEditor.Append(true)
+	c := scrollbackAppend()
 	defer c.End()
 
 	fn := th.Function
@@ -1760,20 +2055,20 @@ func writeGoroutineLong(w io.Writer, g *api.Goroutine, prefix string) {
 		prefix, formatLocation(g.GoStatementLoc))
 }
 
-func writeLinkToLocation(c *richtext.Ctor, style *style.Style, file string, line int, pc uint64) {
+func writeLinkToLocation(c scrollbackCtor, style *style.Style, file string, line int, pc uint64) {
 	c.SetStyle(richtext.TextStyle{Face: style.Font, Color: linkColor, Flags: richtext.Underline})
-	c.Link(fmt.Sprintf("%s:%d", ShortenFilePath(file), line), linkHoverColor, func() {
-		listingPanel.pinnedLoc = &api.Location{File: file, Line: line, PC: pc}
-		go refreshState(refreshToSameFrame, clearNothing, nil)
+	c.link(fmt.Sprintf("%s:%d", ShortenFilePath(file), line), file, line, linkHoverColor, func() {
+		listingNavigate(&api.Location{File: file, Line: line, PC: pc})
 	})
 	c.SetStyle(richtext.TextStyle{Face: style.Font})
 }
 
-func printStack(c *richtext.Ctor, stack []api.Stackframe, ind string) {
+func printStack(c *scrollbackCtor, stack []api.Stackframe, ind string) {
 	if c == nil {
 		wnd.Lock()
 		defer wnd.Unlock()
-		c = scrollbackEditor.Append(true)
+		sc := scrollbackAppend()
+		c = &sc
 		defer c.End()
 	}
 	if len(stack) == 0 {
@@ -1787,7 +2082,7 @@ func printStack(c *richtext.Ctor, stack []api.Stackframe, ind string) {
 
 	for i := range stack {
 		c.Text(fmt.Sprintf(fmtstr, ind, i, stack[i].PC, stack[i].Function.Name(), s))
-		writeLinkToLocation(c, style, stack[i].File, stack[i].Line, stack[i].PC)
+		writeLinkToLocation(*c, style, stack[i].File, stack[i].Line, stack[i].PC)
 		c.Text("\n")
 
 		for j := range stack[i].Arguments {
@@ -1806,6 +2101,86 @@ func ShortenFilePath(fullPath string) string {
 	return strings.Replace(fullPath, workingDir, ".", 1)
 }
 
+// cmdHistorySaveDelay is how long appendCmdHistory waits for more commands
+// before saving the command history.
+const cmdHistorySaveDelay = 5 * time.Second
+
+// cmdHistorySave is the pending save of the command history, if any.
+var cmdHistorySave *time.Timer
+
+// appendCmdHistory adds cmd to the command history and schedules a save of
+// the configuration, so that a burst of commands is written once. Must be
+// called with the window locked.
+func appendCmdHistory(cmd string) {
+	if cmd != cmdhistory[len(cmdhistory)-1] {
+		cmdhistory = append(cmdhistory, cmd)
+	}
+	if cmdHistorySave != nil {
+		return
+	}
+	cmdHistorySave = time.AfterFunc(cmdHistorySaveDelay, func() {
+		wnd.Lock()
+		defer wnd.Unlock()
+		cmdHistorySave = nil
+		saveConfiguration()
+	})
+}
+
+// flushCmdHistory saves the command history immediately if a save is
+// pending. Must be called with the window locked.
+func flushCmdHistory() {
+	if cmdHistorySave != nil && cmdHistorySave.Stop() {
+		cmdHistorySave = nil
+		saveConfiguration()
+	}
+}
+
+// loadCmdHistory replaces the command history with the one saved for the
+// current debug target.
+func loadCmdHistory() {
+	if BackendServer.debugid == "" || conf.CmdHistory == nil {
+		return
+	}
+	cmdhistory = append(cmdhistory[:1], conf.CmdHistory[BackendServer.debugid]...)
+	historyShown = len(cmdhistory)
+}
+
+// savedCmdHistory returns the last maxCmdHistory commands of the history.
+func savedCmdHistory() []string {
+	h := cmdhistory[1:]
+	if len(h) > maxCmdHistory {
+		h = h[len(h)-maxCmdHistory:]
+	}
+	return append([]string(nil), h...)
+}
+
+func historyCommand(out io.Writer, args string) error {
+	args = strings.TrimSpace(args)
+
+	wnd.Lock()
+	h := append([]string(nil), cmdhistory...)
+	wnd.Unlock()
+
+	if n, err := strconv.Atoi(args); err == nil {
+		if n <= 0 || n >= len(h) {
+			return fmt.Errorf("no command %d in history", n)
+		}
+		if cmd, _ := parseCommand(h[n]); cmd == "history" {
+			return fmt.Errorf("command %d is a history command", n)
+		}
+		fmt.Fprintf(out, "%s %s\n", currentPrompt(), h[n])
+		executeCommand(h[n])
+		return nil
+	}
+
+	for i := 1; i < len(h); i++ {
+		if strings.Contains(h[i], args) {
+			fmt.Fprintf(out, "%5d  %s\n", i, h[i])
+		}
+	}
+	return nil
+}
+
 func executeCommand(cmdstr string) {
 	wnd.Changed()
 	defer wnd.Changed()
@@ -24,6 +24,7 @@ type Configuration struct {
 	Scaling              float64
 	Theme                string
 	StopOnNextBreakpoint bool
+	StopOnPanic          bool
 	DisassemblyFlavour   int
 	StartupFunc          string
 	DefaultStepBehaviour string
@@ -35,6 +36,9 @@ type Configuration struct {
 	SubstitutePath       []SubstitutePathRule
 	FrozenBreakpoints    map[string][]frozenBreakpoint
 	DisabledBreakpoints  map[string][]frozenBreakpoint
+	Sessions             map[string]*Session
+	CmdHistory           map[string][]string
+	SyntaxColors         map[string]*SyntaxColors
 }
 
 type LayoutDescr struct {
@@ -68,6 +72,15 @@ func adjustConfiguration() {
 	if conf.SavedBounds == nil {
 		conf.SavedBounds = make(map[string]rect.Rect)
 	}
+	if conf.SyntaxColors == nil {
+		conf.SyntaxColors = make(map[string]*SyntaxColors)
+	}
+	for _, theme := range themes {
+		if conf.SyntaxColors[theme] == nil {
+			sc := defaultSyntaxColors[theme]
+			conf.SyntaxColors[theme] = &sc
+		}
+	}
 }
 
 func configLoc() string {
@@ -106,6 +119,12 @@ func saveConfiguration() {
 		}
 		conf.FrozenBreakpoints[BackendServer.debugid] = append(conf.FrozenBreakpoints[BackendServer.debugid][:0], FrozenBreakpoints...)
 		conf.DisabledBreakpoints[BackendServer.debugid] = append(conf.DisabledBreakpoints[BackendServer.debugid][:0], DisabledBreakpoints...)
+		if len(cmdhistory) > 1 {
+			if conf.CmdHistory == nil {
+				conf.CmdHistory = make(map[string][]string)
+			}
+			conf.CmdHistory[BackendServer.debugid] = savedCmdHistory()
+		}
 	}
 	fh, err := os.Create(configLoc())
 	if err != nil {
@@ -247,6 +247,7 @@ func formatArray(array []int64, hexaddr bool, mode numberMode, canonical bool, s
 
 func (dv *detailViewer) Update(container *nucular.Window) {
 	w := dv.asyncLoad.showRequest(container)
+	container.Data = dv // replaces the asyncLoad set by showRequest, used to save sessions
 	if w == nil {
 		return
 	}
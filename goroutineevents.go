@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+)
+
+// goroutineEvent describes a breakpoint that stops when a goroutine is
+// created or exits.
+type goroutineEvent struct {
+	Exit   bool   `json:",omitempty"`
+	Filter string `json:",omitempty"` // regular expression matched against the creating function and the start function
+}
+
+const (
+	goroutineCreateFunction = "runtime.newproc"
+	goroutineExitFunction   = "runtime.goexit1"
+)
+
+// GoroutineEvents maps the ID of a breakpoint to the goroutine event it
+// stops on.
+var GoroutineEvents = map[int]goroutineEvent{}
+
+func (ev goroutineEvent) String() string {
+	s := "goroutine creation"
+	if ev.Exit {
+		s = "goroutine exit"
+	}
+	if ev.Filter != "" {
+		s += fmt.Sprintf(" matching %q", ev.Filter)
+	}
+	return s
+}
+
+// setGoroutineEventBreakpoint parses 'break -go-create [fnregex]' and
+// 'break -go-exit [fnregex]'.
+func setGoroutineEventBreakpoint(out io.Writer, tracepoint bool, argstr string) error {
+	argv := strings.SplitN(argstr, " ", 2)
+	var ev goroutineEvent
+	switch argv[0] {
+	case "-go-create":
+	case "-go-exit":
+		ev.Exit = true
+	default:
+		return fmt.Errorf("unknown option %q", argv[0])
+	}
+	if len(argv) > 1 {
+		ev.Filter = strings.TrimSpace(argv[1])
+		if _, err := regexp.Compile(ev.Filter); err != nil {
+			return err
+		}
+	}
+	if createGoroutineEventBreakpoint(out, tracepoint, ev) == nil {
+		return fmt.Errorf("could not set breakpoint on %s", ev)
+	}
+	return nil
+}
+
+func createGoroutineEventBreakpoint(out io.Writer, tracepoint bool, ev goroutineEvent) *api.Breakpoint {
+	requestedBp := &api.Breakpoint{Tracepoint: tracepoint, Goroutine: true, Line: -1}
+	if ev.Exit {
+		requestedBp.FunctionName = goroutineExitFunction
+	} else {
+		requestedBp.FunctionName = goroutineCreateFunction
+		requestedBp.Variables = []string{"fn.fn"}
+	}
+	// the breakpoint must be known as a goroutine event before it is frozen
+	// so that the event is saved with it.
+	bp, err := client.CreateBreakpoint(requestedBp)
+	if err != nil {
+		fmt.Fprintf(out, "Could not create breakpoint: %v\n", err)
+		return nil
+	}
+	GoroutineEvents[bp.ID] = ev
+	fmt.Fprintf(out, "%s set on %s\n", formatBreakpointName(bp, true), ev)
+	freezeBreakpoint(out, bp)
+	return bp
+}
+
+// goroutineEventBreakpoint returns the ID of the first breakpoint stopping
+// on goroutine creation (or exit if exit is set), or -1.
+func goroutineEventBreakpoint(exit bool) int {
+	for i := range FrozenBreakpoints {
+		if ev, ok := GoroutineEvents[FrozenBreakpoints[i].Bp.ID]; ok && ev.Exit == exit {
+			return FrozenBreakpoints[i].Bp.ID
+		}
+	}
+	return -1
+}
+
+// toggleGoroutineEventBreakpoint creates or removes the breakpoint on
+// goroutine creation (or exit, if exit is set), called by the breakpoints
+// panel.
+func toggleGoroutineEventBreakpoint(exit bool) {
+	out := editorWriter{true}
+	if id := goroutineEventBreakpoint(exit); id >= 0 {
+		bp, err := client.ClearBreakpoint(id)
+		if err != nil {
+			fmt.Fprintf(&out, "Could not clear breakpoint: %v\n", err)
+		} else {
+			removeFrozenBreakpoint(bp)
+		}
+	} else {
+		createGoroutineEventBreakpoint(&out, false, goroutineEvent{Exit: exit})
+	}
+	refreshState(refreshToSameFrame, clearBreakpoint, nil)
+}
+
+// goroutineEventFunctions returns the names of the function creating the
+// goroutine and of the function the goroutine starts from, for a thread
+// stopped at a goroutine event breakpoint.
+func goroutineEventFunctions(th *api.Thread, ev goroutineEvent) []string {
+	bpi := th.BreakpointInfo
+	if bpi == nil {
+		return nil
+	}
+	var r []string
+	if ev.Exit {
+		if g := bpi.Goroutine; g != nil {
+			r = append(r, g.GoStatementLoc.Function.Name(), g.StartLoc.Function.Name())
+		}
+		return r
+	}
+	if g := bpi.Goroutine; g != nil {
+		r = append(r, g.UserCurrentLoc.Function.Name())
+	}
+	if len(bpi.Variables) > 0 {
+		if pc, err := strconv.ParseUint(bpi.Variables[0].Value, 0, 64); err == nil {
+			locs, err := client.FindLocation(api.EvalScope{-1, 0, 0}, fmt.Sprintf("*%#x", pc), false)
+			if err == nil && len(locs) > 0 {
+				r = append(r, locs[0].Function.Name())
+			}
+		}
+	}
+	return r
+}
+
+// goroutineEventFiltered returns true if th is stopped at a goroutine event
+// breakpoint whose filter does not match the goroutine.
+func goroutineEventFiltered(th *api.Thread) bool {
+	if th.Breakpoint == nil {
+		return false
+	}
+	ev, ok := GoroutineEvents[th.Breakpoint.ID]
+	if !ok || ev.Filter == "" {
+		return false
+	}
+	re, err := regexp.Compile(ev.Filter)
+	if err != nil {
+		return false
+	}
+	for _, name := range goroutineEventFunctions(th, ev) {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// filteredThreads caches the result of goroutineEventFiltered for the
+// threads of the last state the target stopped at, so that the locations
+// are only looked up once per stop.
+var filteredThreads struct {
+	state    *api.DebuggerState
+	filtered map[int]bool // thread ID -> goroutineEventFiltered
+}
+
+// threadFiltered returns true if th, a thread of state, is stopped at a
+// goroutine event breakpoint whose filter does not match the goroutine.
+func threadFiltered(state *api.DebuggerState, th *api.Thread) bool {
+	if th == nil || th.Breakpoint == nil {
+		return false
+	}
+	if filteredThreads.state != state {
+		filteredThreads.state = state
+		filteredThreads.filtered = make(map[int]bool)
+		for _, th := range state.Threads {
+			filteredThreads.filtered[th.ID] = goroutineEventFiltered(th)
+		}
+	}
+	filtered, ok := filteredThreads.filtered[th.ID]
+	if !ok {
+		filtered = goroutineEventFiltered(th)
+		filteredThreads.filtered[th.ID] = filtered
+	}
+	return filtered
+}
+
+// skipGoroutineEventStop returns true if the only reason the target stopped
+// are goroutine event breakpoints whose filter doesn't match.
+func skipGoroutineEventStop(state *api.DebuggerState) bool {
+	if state == nil || state.Exited || state.Err != nil {
+		return false
+	}
+	filtered := false
+	for _, th := range state.Threads {
+		if th.Breakpoint == nil {
+			continue
+		}
+		if !threadFiltered(state, th) {
+			return false
+		}
+		filtered = true
+	}
+	return filtered
+}
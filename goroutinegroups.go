@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/label"
+)
+
+// goroutineGroup is a set of goroutines with the same stack signature,
+// similar to what panicparse does.
+type goroutineGroup struct {
+	key        string
+	frames     []api.Stackframe
+	members    []int // indexes into goroutinesPanel.goroutines
+	waitReason string
+	labels     []string
+}
+
+// waitReasons caches the names of the wait reasons of the target, read
+// from runtime.waitReasonStrings because their order changes between Go
+// versions.
+var waitReasons struct {
+	mu     sync.Mutex
+	exe    time.Time // modification time of the executable the names were read from
+	loaded bool
+	names  []string
+}
+
+func waitReasonString(reason int64) string {
+	if reason == 0 {
+		return ""
+	}
+	waitReasons.mu.Lock()
+	defer waitReasons.mu.Unlock()
+	if !waitReasons.loaded || !waitReasons.exe.Equal(lastModExe) {
+		waitReasons.loaded, waitReasons.exe, waitReasons.names = true, lastModExe, nil
+		v, err := client.EvalVariable(api.EvalScope{-1, 0, 0}, "runtime.waitReasonStrings", api.LoadConfig{false, 1, 64, 256, -1})
+		if err == nil && v.Unreadable == "" {
+			for _, child := range v.Children {
+				waitReasons.names = append(waitReasons.names, child.Value)
+			}
+		}
+	}
+	if reason < 0 || reason >= int64(len(waitReasons.names)) {
+		return fmt.Sprintf("wait reason %d", reason)
+	}
+	return waitReasons.names[reason]
+}
+
+func goroutineStackSignature(frames []api.Stackframe, waitReason int64) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d", waitReason)
+	for i := range frames {
+		fmt.Fprintf(&buf, "\n%s:%d", frames[i].Function.Name(), frames[i].Line)
+	}
+	return buf.String()
+}
+
+// maxStacktraceRequests is the maximum number of stacktrace requests
+// loadGoroutineGroups sends without waiting for a response.
+const maxStacktraceRequests = 16
+
+// goroutineStacks returns the first depth frames of the stack of every
+// loaded goroutine. If depth is 1 the locations already loaded are used,
+// otherwise the requests are pipelined.
+func goroutineStacks(depth int) [][]api.Stackframe {
+	r := make([][]api.Stackframe, len(goroutinesPanel.goroutines))
+	if depth <= 1 {
+		for i := range goroutinesPanel.goroutines {
+			r[i] = []api.Stackframe{{Location: goroutinesPanel.goroutines[i].CurrentLoc}}
+		}
+		return r
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxStacktraceRequests)
+	for i := range goroutinesPanel.goroutines {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i, gid int) {
+			defer wg.Done()
+			frames, err := client.Stacktrace(gid, depth, 0, nil)
+			if err == nil {
+				if len(frames) > depth {
+					frames = frames[:depth]
+				}
+				r[i] = frames
+			}
+			<-sem
+		}(i, goroutinesPanel.goroutines[i].ID)
+	}
+	wg.Wait()
+	return r
+}
+
+// loadGoroutineGroups reads the stack of every loaded goroutine and groups
+// them by stack signature, biggest groups first.
+func loadGoroutineGroups() error {
+	groups := map[string]*goroutineGroup{}
+	stacks := goroutineStacks(goroutinesPanel.groupDepth)
+	for i := range goroutinesPanel.goroutines {
+		g := &goroutinesPanel.goroutines[i]
+		frames := stacks[i]
+		key := goroutineStackSignature(frames, g.WaitReason)
+		group := groups[key]
+		if group == nil {
+			group = &goroutineGroup{key: key, frames: frames, waitReason: waitReasonString(g.WaitReason)}
+			groups[key] = group
+		}
+		group.members = append(group.members, i)
+		if len(g.Labels) > 0 {
+			lbls := writeGoroutineLabels(g.Labels)
+			found := false
+			for _, l := range group.labels {
+				if l == lbls {
+					found = true
+					break
+				}
+			}
+			if !found {
+				group.labels = append(group.labels, lbls)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		goroutinesPanel.groups = append(goroutinesPanel.groups, *group)
+	}
+	sort.Slice(goroutinesPanel.groups, func(i, j int) bool {
+		gi, gj := &goroutinesPanel.groups[i], &goroutinesPanel.groups[j]
+		if len(gi.members) != len(gj.members) {
+			return len(gi.members) > len(gj.members)
+		}
+		return gi.members[0] < gj.members[0]
+	})
+	return nil
+}
+
+func updateGoroutineGroups(w *nucular.Window, filter string, d, dthread int) {
+	style := w.Master().Style()
+
+	const maxGroupLabels = 3
+
+	for i := range goroutinesPanel.groups {
+		group := &goroutinesPanel.groups[i]
+
+		members := make([]*wrappedGoroutine, 0, len(group.members))
+		for _, idx := range group.members {
+			g := &goroutinesPanel.goroutines[idx]
+			if goroutineMatches(g, filter) {
+				members = append(members, g)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "%d goroutines", len(members))
+		if group.waitReason != "" {
+			fmt.Fprintf(&buf, " [%s]", group.waitReason)
+		}
+		if len(group.frames) > 0 {
+			fmt.Fprintf(&buf, "\n%s", formatLocation2(group.frames[0].Location))
+		} else {
+			fmt.Fprintf(&buf, "\n%s", formatLocation2(goroutineGetDisplayLiocation(&members[0].Goroutine)))
+		}
+		for j, lbls := range group.labels {
+			if j >= maxGroupLabels {
+				fmt.Fprintf(&buf, "\n... and %d more label sets", len(group.labels)-maxGroupLabels)
+				break
+			}
+			fmt.Fprintf(&buf, "\nLabels: %s", lbls)
+		}
+		lbl := buf.String()
+
+		w.Row((posRowHeight / 2) * (strings.Count(lbl, "\n") + 1)).Static()
+		w.LayoutSetWidth(posRowHeight)
+		open := goroutinesPanel.groupOpen[group.key]
+		sym := style.Tab.SymMaximize
+		if open {
+			sym = style.Tab.SymMinimize
+		}
+		if w.Button(label.S(sym), false) {
+			open = !open
+			goroutinesPanel.groupOpen[group.key] = open
+		}
+		w.LayoutFitWidth(goroutinesPanel.id, 100)
+		w.Label(lbl, "LT")
+
+		if !open {
+			continue
+		}
+
+		indent := style.Tab.Indent
+		for j := range group.frames {
+			w.Row(posRowHeight).Static()
+			w.LayoutSetWidthScaled(indent)
+			w.Spacing(1)
+			w.LayoutFitWidth(goroutinesPanel.id, 100)
+			frame := &group.frames[j]
+			w.Label(fmt.Sprintf("%s at %s:%d", frame.Function.Name(), ShortenFilePath(frame.File), frame.Line), "LC")
+		}
+		for _, g := range members {
+			goroutineRow(w, g, d, dthread, indent)
+		}
+	}
+}
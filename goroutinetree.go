@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/label"
+)
+
+// goroutineTreeNode is a goroutine in the creation tree, g is nil if the
+// goroutine has already exited and is only known as the ancestor of a
+// live goroutine.
+type goroutineTreeNode struct {
+	id       int
+	g        *api.Goroutine
+	goLoc    *api.Location // go statement that created this goroutine
+	parent   *goroutineTreeNode
+	children []*goroutineTreeNode
+	live     int // number of live descendants
+}
+
+var goroutineTreePanel = struct {
+	asyncLoad    asyncLoad
+	roots        []*goroutineTreeNode
+	hasAncestors bool
+	open         map[int]bool
+	id           int
+}{
+	open: make(map[int]bool),
+}
+
+func loadGoroutineTree(p *asyncLoad) {
+	goroutineTreePanel.roots = nil
+	goroutineTreePanel.hasAncestors = false
+	goroutineTreePanel.id++
+
+	var gs []*api.Goroutine
+	for start := 0; start >= 0; {
+		page, next, err := client.ListGoroutines(start, 1000)
+		if err != nil {
+			p.done(err)
+			return
+		}
+		gs = append(gs, page...)
+		start = next
+	}
+
+	nodes := make(map[int]*goroutineTreeNode, len(gs))
+	getNode := func(id int) *goroutineTreeNode {
+		n := nodes[id]
+		if n == nil {
+			n = &goroutineTreeNode{id: id}
+			nodes[id] = n
+		}
+		return n
+	}
+
+	for _, g := range gs {
+		n := getNode(g.ID)
+		n.g = g
+		n.goLoc = &g.GoStatementLoc
+	}
+
+	for _, g := range gs {
+		ancestors, err := client.Ancestors(g.ID, NumAncestors, 1)
+		if err != nil || len(ancestors) == 0 {
+			continue
+		}
+		goroutineTreePanel.hasAncestors = true
+		child := nodes[g.ID]
+		for i := range ancestors {
+			if ancestors[i].Unreadable != "" {
+				break
+			}
+			parent := getNode(int(ancestors[i].ID))
+			if parent.g == nil && parent.goLoc == nil && i+1 < len(ancestors) && len(ancestors[i+1].Stack) > 0 {
+				// the top frame of the grandparent's stack is the go
+				// statement that created parent
+				parent.goLoc = &ancestors[i+1].Stack[0].Location
+			}
+			if child.parent != nil {
+				// the rest of the chain was already added by a sibling
+				break
+			}
+			child.parent = parent
+			parent.children = append(parent.children, child)
+			child = parent
+		}
+	}
+
+	for _, n := range nodes {
+		if n.parent == nil {
+			goroutineTreePanel.roots = append(goroutineTreePanel.roots, n)
+		}
+	}
+	for _, n := range goroutineTreePanel.roots {
+		n.countLive()
+	}
+	sortGoroutineTreeNodes(goroutineTreePanel.roots)
+
+	p.done(nil)
+}
+
+func (n *goroutineTreeNode) countLive() int {
+	n.live = 0
+	for _, child := range n.children {
+		n.live += child.countLive()
+		if child.g != nil {
+			n.live++
+		}
+	}
+	sortGoroutineTreeNodes(n.children)
+	return n.live
+}
+
+func sortGoroutineTreeNodes(nodes []*goroutineTreeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].live != nodes[j].live {
+			return nodes[i].live > nodes[j].live
+		}
+		return nodes[i].id < nodes[j].id
+	})
+}
+
+func updateGoroutineTree(container *nucular.Window) {
+	w := goroutineTreePanel.asyncLoad.showRequest(container)
+	if w == nil {
+		return
+	}
+
+	if !goroutineTreePanel.hasAncestors {
+		w.Row(posRowHeight).Dynamic(1)
+		w.Label(fmt.Sprintf("Ancestors not available, the target must be started with GODEBUG=tracebackancestors=%d", NumAncestors), "LC")
+	}
+
+	for _, n := range goroutineTreePanel.roots {
+		updateGoroutineTreeNode(w, n, 0)
+	}
+}
+
+func updateGoroutineTreeNode(w *nucular.Window, n *goroutineTreeNode, depth int) {
+	style := w.Master().Style()
+
+	w.Row(posRowHeight).Static()
+	if depth > 0 {
+		w.LayoutSetWidthScaled(depth * style.Tab.Indent)
+		w.Spacing(1)
+	}
+
+	w.LayoutSetWidth(posRowHeight)
+	open := goroutineTreePanel.open[n.id]
+	if len(n.children) > 0 {
+		sym := style.Tab.SymMaximize
+		if open {
+			sym = style.Tab.SymMinimize
+		}
+		if w.Button(label.S(sym), false) {
+			open = !open
+			goroutineTreePanel.open[n.id] = open
+		}
+	} else {
+		w.Spacing(1)
+	}
+
+	lbl := fmt.Sprintf("Goroutine %d", n.id)
+	if n.g == nil {
+		lbl += " (exited)"
+	}
+	if n.live > 0 {
+		lbl += fmt.Sprintf(" [%d live descendants]", n.live)
+	}
+	if n.goLoc != nil && n.goLoc.PC != 0 {
+		lbl += fmt.Sprintf(" created at %s:%d", ShortenFilePath(n.goLoc.File), n.goLoc.Line)
+	}
+
+	w.LayoutFitWidth(goroutineTreePanel.id, 100)
+	selected := n.g != nil && n.id == curGid
+	if w.SelectableLabel(lbl, "LC", &selected) && n.g != nil && n.id != curGid && !client.Running() {
+		go switchGoroutine(n.id, refreshToFrameZero)
+	}
+
+	if open {
+		for _, child := range n.children {
+			updateGoroutineTreeNode(w, child, depth+1)
+		}
+	}
+}
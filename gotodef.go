@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+)
+
+// sourceFile is a parsed source file of the target.
+type sourceFile struct {
+	path string // path reported by delve
+	ast  *ast.File
+	src  []byte
+}
+
+// parseSourceFile parses the source file at path, reading it from the
+// location specified by the substitute path rules.
+func parseSourceFile(fset *token.FileSet, path string) (*sourceFile, error) {
+	src, err := ioutil.ReadFile(conf.substitutePath(path))
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseFile(fset, path, src, 0)
+	if f == nil {
+		return nil, err
+	}
+	return &sourceFile{path: path, ast: f, src: src}, nil
+}
+
+// line returns the text of the n-th line of sf.
+func (sf *sourceFile) line(n int) string {
+	lines := bytes.SplitN(sf.src, []byte{'\n'}, n+1)
+	if n-1 < len(lines) {
+		return string(lines[n-1])
+	}
+	return ""
+}
+
+// packageSources returns the Go source files of the target contained in
+// directories matching dirMatch.
+func packageSources(dirMatch func(dir string) bool) []string {
+	wnd.Lock()
+	sources := sourcesPanel.slice
+	wnd.Unlock()
+
+	if len(sources) == 0 {
+		sources, _ = client.ListSources("")
+	}
+
+	r := []string{}
+	for _, source := range sources {
+		if !strings.HasSuffix(source, ".go") || strings.HasSuffix(source, "_test.go") {
+			continue
+		}
+		if dirMatch(filepath.ToSlash(filepath.Dir(source))) {
+			r = append(r, source)
+		}
+	}
+	return r
+}
+
+// parsePackage parses all files in the same directory as path.
+func parsePackage(fset *token.FileSet, path string) []*sourceFile {
+	dir := filepath.ToSlash(filepath.Dir(path))
+	files := packageSources(func(d string) bool { return d == dir })
+	found := false
+	for _, file := range files {
+		if file == path {
+			found = true
+		}
+	}
+	if !found {
+		files = append(files, path)
+	}
+	return parseFiles(fset, files)
+}
+
+// parseImportedPackage parses all files of the package with the specified
+// import path.
+func parseImportedPackage(fset *token.FileSet, importPath string) []*sourceFile {
+	if dir := importedPackageDir(importPath); dir != "" {
+		return parseFiles(fset, packageSources(func(d string) bool { return d == dir }))
+	}
+	return parseFiles(fset, packageSources(func(d string) bool { return importPathDir(d, importPath) }))
+}
+
+// importedPackageDir returns the directory containing the package with the
+// specified import path, found through the location of one of its
+// functions, or an empty string if the package has no functions.
+func importedPackageDir(importPath string) string {
+	const maxTries = 5
+	funcs, _ := client.ListFunctions("^" + regexp.QuoteMeta(importPath) + `\.`)
+	for i, fn := range funcs {
+		if i >= maxTries {
+			break
+		}
+		locs, err := client.FindLocation(api.EvalScope{-1, 0, 0}, fn, false)
+		if err == nil && len(locs) > 0 && strings.HasSuffix(locs[0].File, ".go") {
+			return filepath.ToSlash(filepath.Dir(locs[0].File))
+		}
+	}
+	return ""
+}
+
+// importPathDir returns true if dir could contain the package with the
+// specified import path. Module versions (@vX.Y.Z) and the escaping of upper
+// case letters used by the module cache are removed from dir.
+func importPathDir(dir, importPath string) bool {
+	elems := strings.Split(dir, "/")
+	for i := range elems {
+		if at := strings.Index(elems[i], "@"); at >= 0 {
+			elems[i] = elems[i][:at]
+		}
+		if strings.Contains(elems[i], "!") {
+			var buf strings.Builder
+			for j := 0; j < len(elems[i]); j++ {
+				if elems[i][j] == '!' && j+1 < len(elems[i]) {
+					j++
+					buf.WriteString(strings.ToUpper(elems[i][j : j+1]))
+					continue
+				}
+				buf.WriteByte(elems[i][j])
+			}
+			elems[i] = buf.String()
+		}
+	}
+	d := strings.Join(elems, "/")
+	return d == importPath || strings.HasSuffix(d, "/"+importPath)
+}
+
+// qualifiedDecl returns the position of the function called name in the
+// package with the specified import path.
+func qualifiedDecl(importPath, name string) []token.Position {
+	locs, err := client.FindLocation(currentEvalScope(), importPath+"."+name, false)
+	if err != nil || len(locs) != 1 || locs[0].Function == nil || locs[0].Function.Name() != importPath+"."+name {
+		return nil
+	}
+	return []token.Position{{Filename: locs[0].File, Line: locs[0].Line}}
+}
+
+func parseFiles(fset *token.FileSet, files []string) []*sourceFile {
+	r := []*sourceFile{}
+	for _, file := range files {
+		sf, err := parseSourceFile(fset, file)
+		if err == nil {
+			r = append(r, sf)
+		}
+	}
+	return r
+}
+
+// identAt returns the identifier at line and col (a 1-based byte offset) of
+// f and, if the identifier is the selector of a selector expression, the
+// expression.
+func identAt(fset *token.FileSet, f *ast.File, line, col int) (*ast.Ident, *ast.SelectorExpr) {
+	var ident *ast.Ident
+	var sel *ast.SelectorExpr
+	selectors := map[*ast.Ident]*ast.SelectorExpr{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ident != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			selectors[n.Sel] = n
+		case *ast.Ident:
+			pos := fset.Position(n.Pos())
+			if pos.Line == line && col >= pos.Column && col < pos.Column+len(n.Name) {
+				ident = n
+				sel = selectors[n]
+			}
+		}
+		return true
+	})
+	return ident, sel
+}
+
+// importPathOf returns the import path of the package imported as name in f.
+func importPathOf(f *ast.File, name string) (string, bool) {
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		impname := filepath.Base(path)
+		if imp.Name != nil {
+			impname = imp.Name.Name
+		}
+		if impname == name {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// packageQualifier returns the import path of the package selected by sel,
+// if sel.X is the name of an imported package.
+func packageQualifier(f *ast.File, sel *ast.SelectorExpr) (string, bool) {
+	if sel == nil {
+		return "", false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok || x.Obj != nil {
+		return "", false
+	}
+	return importPathOf(f, x.Name)
+}
+
+// topLevelDecls returns the position of the package level declarations of
+// name in files.
+func topLevelDecls(fset *token.FileSet, files []*sourceFile, name string) []token.Position {
+	r := []token.Position{}
+	for _, sf := range files {
+		if obj := sf.ast.Scope.Lookup(name); obj != nil {
+			r = append(r, fset.Position(obj.Pos()))
+		}
+	}
+	return r
+}
+
+// memberDecls returns the position of the declarations of methods, struct
+// fields and interface methods called name in files.
+func memberDecls(fset *token.FileSet, files []*sourceFile, name string) []token.Position {
+	r := []token.Position{}
+	fields := func(fl *ast.FieldList) {
+		for _, field := range fl.List {
+			for _, fname := range field.Names {
+				if fname.Name == name {
+					r = append(r, fset.Position(fname.Pos()))
+				}
+			}
+		}
+	}
+	for _, sf := range files {
+		ast.Inspect(sf.ast, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Recv != nil && n.Name.Name == name {
+					r = append(r, fset.Position(n.Name.Pos()))
+				}
+			case *ast.StructType:
+				fields(n.Fields)
+			case *ast.InterfaceType:
+				fields(n.Methods)
+			}
+			return true
+		})
+	}
+	return r
+}
+
+// findDefinition returns the possible declarations of the identifier at
+// file:line:col.
+func findDefinition(file string, line, col int) (string, []token.Position, error) {
+	var fset token.FileSet
+	sf, err := parseSourceFile(&fset, file)
+	if err != nil {
+		return "", nil, err
+	}
+	ident, sel := identAt(&fset, sf.ast, line, col)
+	if ident == nil {
+		return "", nil, fmt.Errorf("no identifier at %s:%d:%d", ShortenFilePath(file), line, col)
+	}
+
+	switch {
+	case ident.Obj != nil && ident.Obj.Pos().IsValid():
+		return ident.Name, []token.Position{fset.Position(ident.Obj.Pos())}, nil
+
+	case sel != nil:
+		if importPath, ok := packageQualifier(sf.ast, sel); ok {
+			if decls := qualifiedDecl(importPath, ident.Name); decls != nil {
+				return ident.Name, decls, nil
+			}
+			return ident.Name, topLevelDecls(&fset, parseImportedPackage(&fset, importPath), ident.Name), nil
+		}
+		return ident.Name, memberDecls(&fset, parsePackage(&fset, file), ident.Name), nil
+
+	default:
+		return ident.Name, topLevelDecls(&fset, parsePackage(&fset, file), ident.Name), nil
+	}
+}
+
+// goToDefinition shows the declaration of the identifier at file:line:col in
+// the Listing panel. If the declaration is ambiguous all candidates are
+// printed.
+func goToDefinition(out io.Writer, file string, line, col int) {
+	name, decls, err := findDefinition(file, line, col)
+	if err != nil {
+		fmt.Fprintf(out, "Could not find definition: %v\n", err)
+		return
+	}
+	if len(decls) == 0 {
+		fmt.Fprintf(out, "Could not find definition of %s\n", name)
+		return
+	}
+	if len(decls) > 1 {
+		printSourcePositions(fmt.Sprintf("%d definitions of %s:\n", len(decls), name), decls, nil)
+	}
+	wnd.Lock()
+	listingNavigate(&api.Location{File: decls[0].Filename, Line: decls[0].Line})
+	wnd.Unlock()
+}
+
+// findReferences returns the references in its package to the identifier
+// at file:line:col.
+func findReferences(file string, line, col int) (string, []token.Position, map[string]*sourceFile, error) {
+	var fset token.FileSet
+	sf, err := parseSourceFile(&fset, file)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	ident, sel := identAt(&fset, sf.ast, line, col)
+	if ident == nil {
+		return "", nil, nil, fmt.Errorf("no identifier at %s:%d:%d", ShortenFilePath(file), line, col)
+	}
+
+	importPath, qualified := packageQualifier(sf.ast, sel)
+	local := ident.Obj != nil && sf.ast.Scope.Lookup(ident.Name) != ident.Obj
+
+	var files []*sourceFile
+	if local {
+		files = []*sourceFile{sf}
+	} else {
+		files = parsePackage(&fset, file)
+	}
+
+	byPath := map[string]*sourceFile{}
+	r := []token.Position{}
+	for _, f := range files {
+		byPath[f.path] = f
+		qualifier := ""
+		if qualified {
+			// the package could be imported with a different name
+			for _, imp := range f.ast.Imports {
+				if p, _ := strconv.Unquote(imp.Path.Value); p == importPath {
+					qualifier = filepath.Base(p)
+					if imp.Name != nil {
+						qualifier = imp.Name.Name
+					}
+				}
+			}
+			if qualifier == "" {
+				continue
+			}
+		}
+
+		selectors := map[*ast.Ident]*ast.SelectorExpr{}
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				selectors[n.Sel] = n
+			case *ast.Ident:
+				if n.Name != ident.Name {
+					break
+				}
+				nsel := selectors[n]
+				switch {
+				case local:
+					if n.Obj != ident.Obj {
+						return true
+					}
+				case qualified:
+					if nsel == nil {
+						return true
+					}
+					if x, ok := nsel.X.(*ast.Ident); !ok || x.Name != qualifier || x.Obj != nil {
+						return true
+					}
+				case sel != nil:
+					if nsel == nil {
+						return true
+					}
+					if _, ok := packageQualifier(f.ast, nsel); ok {
+						return true
+					}
+				default:
+					if nsel != nil || (n.Obj != nil && n.Obj != f.ast.Scope.Lookup(n.Name)) {
+						return true
+					}
+				}
+				r = append(r, fset.Position(n.Pos()))
+			}
+			return true
+		})
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Filename != r[j].Filename {
+			return r[i].Filename < r[j].Filename
+		}
+		return r[i].Offset < r[j].Offset
+	})
+	return ident.Name, r, byPath, nil
+}
+
+func printReferences(out io.Writer, file string, line, col int) {
+	name, refs, files, err := findReferences(file, line, col)
+	if err != nil {
+		fmt.Fprintf(out, "Could not find references: %v\n", err)
+		return
+	}
+	printSourcePositions(fmt.Sprintf("%d references to %s in package:\n", len(refs), name), refs, files)
+}
+
+// printSourcePositions prints a link to each position, followed by the
+// text of the line if the file is in files.
+func printSourcePositions(header string, positions []token.Position, files map[string]*sourceFile) {
+	wnd.Lock()
+	defer wnd.Unlock()
+	style := wnd.Style()
+	c := scrollbackAppend()
+	defer c.End()
+	c.Text(header)
+	for _, pos := range positions {
+		c.Text("\t")
+		writeLinkToLocation(c, style, pos.Filename, pos.Line, 0)
+		if sf := files[pos.Filename]; sf != nil {
+			c.Text(": " + strings.TrimSpace(sf.line(pos.Line)))
+		}
+		c.Text("\n")
+	}
+}
+
+// identNameAt returns the identifier containing the byte at col (1-based)
+// of text.
+func identNameAt(text string, col int) string {
+	isIdentChar := func(ch byte) bool {
+		return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch >= 0x80
+	}
+	i := col - 1
+	if i < 0 || i >= len(text) || !isIdentChar(text[i]) {
+		return ""
+	}
+	start, end := i, i
+	for start > 0 && isIdentChar(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isIdentChar(text[end]) {
+		end++
+	}
+	if text[start] >= '0' && text[start] <= '9' {
+		return ""
+	}
+	return text[start:end]
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"image/color"
+	"path/filepath"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+)
+
+// syntaxClass is the class of a token for syntax highlighting.
+type syntaxClass uint8
+
+const (
+	syntaxPlain syntaxClass = iota
+	syntaxKeyword
+	syntaxBuiltin
+	syntaxString
+	syntaxNumber
+	syntaxComment
+	syntaxDirective
+	numSyntaxClasses
+)
+
+var syntaxClassNames = [numSyntaxClasses]string{"Plain", "Keyword", "Builtin", "String", "Number", "Comment", "Directive"}
+
+// syntaxSpan is a highlighted range of bytes of a line.
+type syntaxSpan struct {
+	start, end int
+	class      syntaxClass
+}
+
+// SyntaxColors are the colors used for syntax highlighting by a theme, in
+// #rrggbb format.
+type SyntaxColors struct {
+	Keyword, Builtin, String, Number, Comment, Directive string
+}
+
+func (sc *SyntaxColors) field(class syntaxClass) *string {
+	switch class {
+	case syntaxKeyword:
+		return &sc.Keyword
+	case syntaxBuiltin:
+		return &sc.Builtin
+	case syntaxString:
+		return &sc.String
+	case syntaxNumber:
+		return &sc.Number
+	case syntaxComment:
+		return &sc.Comment
+	case syntaxDirective:
+		return &sc.Directive
+	}
+	return nil
+}
+
+var darkSyntaxColors = SyntaxColors{
+	Keyword:   "#cc7832",
+	Builtin:   "#9876aa",
+	String:    "#6a8759",
+	Number:    "#6897bb",
+	Comment:   "#808080",
+	Directive: "#bbb529",
+}
+
+var lightSyntaxColors = SyntaxColors{
+	Keyword:   "#000080",
+	Builtin:   "#660e7a",
+	String:    "#067d17",
+	Number:    "#1750eb",
+	Comment:   "#8c8c8c",
+	Directive: "#9e880d",
+}
+
+var defaultSyntaxColors = map[string]SyntaxColors{
+	darkTheme:   darkSyntaxColors,
+	whiteTheme:  lightSyntaxColors,
+	redTheme:    darkSyntaxColors,
+	boringTheme: lightSyntaxColors,
+}
+
+// syntaxPalette is the color of each syntax class for the current theme.
+var syntaxPalette [numSyntaxClasses]color.RGBA
+
+// setupSyntaxColors sets syntaxPalette from the configuration of the
+// current theme, invalid colors are replaced by the theme's default.
+func setupSyntaxColors() {
+	defaults, ok := defaultSyntaxColors[conf.Theme]
+	if !ok {
+		defaults = darkSyntaxColors
+	}
+	sc := conf.SyntaxColors[conf.Theme]
+	for class := syntaxKeyword; class < numSyntaxClasses; class++ {
+		c, err := parseColor(*defaults.field(class))
+		if sc != nil {
+			if c2, err2 := parseColor(*sc.field(class)); err2 == nil {
+				c, err = c2, nil
+			}
+		}
+		if err == nil {
+			syntaxPalette[class] = c
+		}
+	}
+}
+
+// highlightSource returns the syntax highlighting spans of each line of
+// file, selecting the highlighter from the file extension. Returns nil if
+// the language of file isn't supported.
+func highlightSource(file string, lines []string) [][]syntaxSpan {
+	src := []byte(strings.Join(lines, "\n"))
+	var toks []syntaxSpan
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".go":
+		toks = highlightGo(src)
+	case ".s":
+		toks = highlightCLike(src, nil, asmBuiltins, true)
+	case ".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx", ".m":
+		toks = highlightCLike(src, cKeywords, cBuiltins, false)
+	default:
+		return nil
+	}
+	return splitSpans(lines, toks)
+}
+
+// splitSpans splits toks, a list of spans over the concatenation of lines,
+// into spans of each line.
+func splitSpans(lines []string, toks []syntaxSpan) [][]syntaxSpan {
+	r := make([][]syntaxSpan, len(lines))
+	lineStart := 0
+	i := 0
+	for k, line := range lines {
+		lineEnd := lineStart + len(line)
+		for ; i < len(toks) && toks[i].start < lineEnd; i++ {
+			start, end := toks[i].start, toks[i].end
+			if start < lineStart {
+				start = lineStart
+			}
+			if end > lineEnd {
+				end = lineEnd
+			}
+			if start < end {
+				r[k] = append(r[k], syntaxSpan{start - lineStart, end - lineStart, toks[i].class})
+			}
+			if toks[i].end > lineEnd {
+				// the token continues on the next line
+				break
+			}
+		}
+		lineStart = lineEnd + 1
+	}
+	return r
+}
+
+var goBuiltins = map[string]bool{
+	"bool": true, "byte": true, "complex64": true, "complex128": true, "error": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true, "rune": true, "string": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true, "any": true, "comparable": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true, "delete": true, "imag": true, "len": true,
+	"make": true, "max": true, "min": true, "new": true, "panic": true, "print": true, "println": true, "real": true, "recover": true,
+}
+
+func highlightGo(src []byte) []syntaxSpan {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	r := []syntaxSpan{}
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		off := file.Offset(pos)
+		end := off + len(lit)
+		class := syntaxPlain
+		switch {
+		case tok.IsKeyword():
+			class, end = syntaxKeyword, off+len(tok.String())
+		case tok == token.IDENT && goBuiltins[lit]:
+			class = syntaxBuiltin
+		case tok == token.STRING || tok == token.CHAR:
+			class = syntaxString
+			if strings.HasPrefix(lit, "`") {
+				// lit does not contain carriage returns
+				end = tokenEnd(src, off+1, "`")
+			}
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = syntaxNumber
+		case tok == token.COMMENT:
+			class = syntaxComment
+			if strings.HasPrefix(lit, "/*") {
+				end = tokenEnd(src, off+2, "*/")
+			} else if strings.HasPrefix(lit, "//go:") || strings.HasPrefix(lit, "//line ") {
+				class = syntaxDirective
+			}
+		}
+		if class != syntaxPlain {
+			r = append(r, syntaxSpan{off, end, class})
+		}
+	}
+	return r
+}
+
+// tokenEnd returns the offset after the first occurrence of terminator in
+// src at or after off, or len(src).
+func tokenEnd(src []byte, off int, terminator string) int {
+	if off > len(src) {
+		return len(src)
+	}
+	i := bytes.Index(src[off:], []byte(terminator))
+	if i < 0 {
+		return len(src)
+	}
+	return off + i + len(terminator)
+}
+
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "const": true, "continue": true, "default": true, "do": true, "else": true,
+	"enum": true, "extern": true, "for": true, "goto": true, "if": true, "inline": true, "register": true, "restrict": true,
+	"return": true, "sizeof": true, "static": true, "struct": true, "switch": true, "typedef": true, "union": true,
+	"volatile": true, "while": true,
+	// C++
+	"catch": true, "class": true, "const_cast": true, "constexpr": true, "decltype": true, "delete": true, "dynamic_cast": true,
+	"explicit": true, "friend": true, "mutable": true, "namespace": true, "new": true, "noexcept": true, "operator": true,
+	"override": true, "private": true, "protected": true, "public": true, "reinterpret_cast": true, "static_cast": true,
+	"template": true, "this": true, "throw": true, "try": true, "typename": true, "using": true, "virtual": true,
+}
+
+var cBuiltins = map[string]bool{
+	"bool": true, "_Bool": true, "char": true, "double": true, "float": true, "int": true, "long": true, "short": true,
+	"signed": true, "unsigned": true, "void": true, "size_t": true, "ssize_t": true, "uintptr_t": true, "intptr_t": true,
+	"int8_t": true, "int16_t": true, "int32_t": true, "int64_t": true, "uint8_t": true, "uint16_t": true, "uint32_t": true, "uint64_t": true,
+	"NULL": true, "nullptr": true, "true": true, "false": true,
+}
+
+// asmBuiltins are the pseudo-registers of the Go assembler.
+var asmBuiltins = map[string]bool{
+	"SB": true, "FP": true, "SP": true, "PC": true,
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch >= 0x80
+}
+
+// highlightCLike highlights C, C++ and, if asm is set, Go assembly source
+// code. In assembly the first identifier of each statement (the
+// instruction) is highlighted as a keyword.
+func highlightCLike(src []byte, keywords, builtins map[string]bool, asm bool) []syntaxSpan {
+	r := []syntaxSpan{}
+	lineStart := true // only whitespace since the start of the line
+	stmtStart := true // no instruction since the start of the statement
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+		start := i
+		switch {
+		case ch == '\n':
+			lineStart, stmtStart = true, true
+			i++
+			continue
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+			continue
+		case ch == ';':
+			stmtStart = true
+			lineStart = false
+			i++
+			continue
+		case bytes.HasPrefix(src[i:], []byte("//")):
+			i = tokenEnd(src, i, "\n")
+			if i > start && src[i-1] == '\n' {
+				i--
+			}
+			r = append(r, syntaxSpan{start, i, syntaxComment})
+			continue
+		case bytes.HasPrefix(src[i:], []byte("/*")):
+			i = tokenEnd(src, i+2, "*/")
+			r = append(r, syntaxSpan{start, i, syntaxComment})
+			continue
+		case ch == '#' && lineStart:
+			for i < len(src) && src[i] != '\n' && !bytes.HasPrefix(src[i:], []byte("//")) && !bytes.HasPrefix(src[i:], []byte("/*")) {
+				i++
+			}
+			r = append(r, syntaxSpan{start, i, syntaxDirective})
+		case ch == '"' || ch == '\'':
+			i++
+			for i < len(src) && src[i] != ch && src[i] != '\n' {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				i++
+			}
+			if i < len(src) && src[i] == ch {
+				i++
+			}
+			r = append(r, syntaxSpan{start, i, syntaxString})
+		case (ch >= '0' && ch <= '9') || (asm && ch == '$' && i+1 < len(src) && (src[i+1] == '-' || (src[i+1] >= '0' && src[i+1] <= '9'))):
+			i++
+			for i < len(src) && (isIdentByte(src[i]) || src[i] == '.' || (src[i] == '-' && src[start] == '$')) {
+				i++
+			}
+			r = append(r, syntaxSpan{start, i, syntaxNumber})
+		case isIdentByte(ch):
+			for i < len(src) && (isIdentByte(src[i]) || (asm && src[i] == '.')) {
+				i++
+			}
+			word := string(src[start:i])
+			switch {
+			case asm && stmtStart:
+				if i < len(src) && src[i] == ':' {
+					// label
+					i++
+					lineStart = false
+					continue
+				}
+				r = append(r, syntaxSpan{start, i, syntaxKeyword})
+			case keywords[word]:
+				r = append(r, syntaxSpan{start, i, syntaxKeyword})
+			case builtins[word]:
+				r = append(r, syntaxSpan{start, i, syntaxBuiltin})
+			}
+		default:
+			i++
+		}
+		lineStart, stmtStart = false, false
+	}
+	return r
+}
+
+// expandSpans converts spans of the line raw to spans of the same line
+// after tab expansion.
+func expandSpans(raw string, spans []syntaxSpan) []syntaxSpan {
+	if strings.IndexByte(raw, '\t') < 0 {
+		return spans
+	}
+	for i := range spans {
+		spans[i].start = len(expandTabs(raw[:spans[i].start]))
+		spans[i].end = len(expandTabs(raw[:spans[i].end]))
+	}
+	return spans
+}
+
+// highlightedLabel draws text like Label, coloring spans.
+func highlightedLabel(w *nucular.Window, text string, spans []syntaxSpan) {
+	if len(spans) == 0 {
+		w.Label(text, "LC")
+		return
+	}
+
+	// the invisible label is used for layout
+	w.LabelColored(text, "LC", color.RGBA{})
+
+	style := w.Master().Style()
+	b := w.LastWidgetBounds
+	fh := nucular.FontHeight(style.Font)
+	r := rect.Rect{X: b.X + style.Text.Padding.X, Y: b.Y + b.H/2 - fh/2, H: 2 * fh}
+	cmds := w.Commands()
+	draw := func(s string, c color.RGBA) {
+		if s == "" {
+			return
+		}
+		r.W = b.X + b.W - r.X
+		cmds.DrawText(r, s, style.Font, c)
+		r.X += nucular.FontWidth(style.Font, s)
+	}
+
+	pos := 0
+	for _, span := range spans {
+		if span.start < pos || span.end > len(text) {
+			break
+		}
+		draw(text[pos:span.start], style.Text.Color)
+		draw(text[span.start:span.end], syntaxPalette[span.class])
+		pos = span.end
+	}
+	draw(text[pos:], style.Text.Color)
+}
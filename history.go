@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+	nstyle "github.com/aarzilli/nucular/style"
+)
+
+// exprHistory is the value of an expression at every autocheckpoint.
+type exprHistory struct {
+	mu      sync.Mutex
+	expr    string
+	loading bool
+	err     error
+	rows    []exprHistoryRow
+	numeric bool // all values are numbers and can be plotted
+}
+
+type exprHistoryRow struct {
+	check   autoCheckpoint
+	value   string
+	num     float64
+	changed bool // value is different from the previous row
+}
+
+const exprHistoryPlotHeight = 100
+
+var exprHistoryChangedColor = color.RGBA{0xd0, 0x00, 0x00, 0x60}
+
+func openExprHistoryWindow(mw nucular.MasterWindow, expr string) {
+	h := &exprHistory{expr: expr, loading: true}
+	go h.load()
+	mw.PopupOpen(fmt.Sprintf("History of %s", expr), popupFlags|nucular.WindowNonmodal|nucular.WindowScalable|nucular.WindowClosable, rect.Rect{100, 100, 600, 500}, true, h.update)
+}
+
+// evalHistoryExpr evaluates expr at the current autocheckpoint. Expressions
+// that are not pinned to a frame are evaluated on the topmost frame of the
+// goroutine that hit the checkpoint.
+func evalHistoryExpr(expr string, cfg api.LoadConfig) *api.Variable {
+	se := ParseScopedExpr(expr)
+	if se.Kind != NormalScopeExpr || se.Gid >= 0 || se.Fid >= 0 || (len(se.EvalExpr) > 0 && se.EvalExpr[0] == '$') {
+		return evalScopedExpr(expr, cfg)
+	}
+	v, err := client.EvalVariable(api.EvalScope{GoroutineID: -1}, se.EvalExpr, cfg)
+	if err != nil {
+		return &api.Variable{Name: expr, Unreadable: err.Error()}
+	}
+	return v
+}
+
+func historyNumber(v *api.Variable) (float64, bool) {
+	if v.Unreadable != "" {
+		return 0, false
+	}
+	switch v.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(v.Value, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// load evaluates the expression at every autocheckpoint, then moves the
+// target back where it was.
+func (h *exprHistory) load() {
+	defer wnd.Changed()
+	defer func() {
+		h.mu.Lock()
+		h.loading = false
+		h.mu.Unlock()
+	}()
+
+	if !client.Recorded() {
+		h.setErr(fmt.Errorf("not a recording"))
+		return
+	}
+
+	autoCheckpointsPanel.mu.Lock()
+	checks := append([]autoCheckpoint(nil), autoCheckpointsPanel.checkpoints...)
+	autoCheckpointsPanel.mu.Unlock()
+	if len(checks) == 0 {
+		h.setErr(fmt.Errorf("no autocheckpoints, create them from the AutoCheckpoints window"))
+		return
+	}
+
+	start, err := client.Checkpoint("history")
+	if err != nil {
+		h.setErr(err)
+		return
+	}
+	defer func() {
+		client.RestartFrom(false, fmt.Sprintf("c%d", start), false, nil, [3]string{}, false)
+		client.ClearCheckpoint(start)
+		refreshState(refreshToFrameZero, clearStop, nil)
+	}()
+
+	cfg := getVariableLoadConfig()
+	rows := make([]exprHistoryRow, 0, len(checks))
+	numeric := true
+	for i, check := range checks {
+		row := exprHistoryRow{check: check}
+		if err := restartCheckpointToGoroutine(check.ID, check.GoroutineID); err != nil {
+			row.value = fmt.Sprintf("<%v>", err)
+		} else {
+			v := evalHistoryExpr(h.expr, cfg)
+			row.value = wrapApiVariableSimple(v).SinglelineString(true, true)
+			var ok bool
+			row.num, ok = historyNumber(v)
+			numeric = numeric && ok
+		}
+		row.changed = i > 0 && row.value != rows[i-1].value
+		rows = append(rows, row)
+	}
+
+	h.mu.Lock()
+	h.rows = rows
+	h.numeric = numeric
+	h.mu.Unlock()
+}
+
+func (h *exprHistory) setErr(err error) {
+	h.mu.Lock()
+	h.err = err
+	h.mu.Unlock()
+}
+
+func (h *exprHistory) update(w *nucular.Window) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.loading {
+		w.Row(varRowHeight).Dynamic(1)
+		w.Label("Loading...", "LC")
+		return
+	}
+	if h.err != nil {
+		w.Row(varRowHeight).Dynamic(1)
+		w.Label(fmt.Sprintf("Error: %v", h.err), "LC")
+		return
+	}
+
+	style := w.Master().Style()
+
+	if h.numeric && len(h.rows) > 1 {
+		w.Row(exprHistoryPlotHeight).Dynamic(1)
+		h.plot(w, style)
+	}
+
+	for i := range h.rows {
+		row := &h.rows[i]
+		w.Row(varRowHeight).Static(100, 100, 0)
+		if row.changed {
+			b := w.WidgetBounds()
+			b.W = w.Bounds.X + w.Bounds.W - b.X
+			w.Commands().FillRect(b, 0, exprHistoryChangedColor)
+		}
+		w.Label(fmt.Sprintf("c%d,%s", row.check.ID, row.check.Where), "LC")
+		w.Label(fmt.Sprintf("goroutine %d", row.check.GoroutineID), "LC")
+		selected := false
+		if w.SelectableLabel(row.value, "LC", &selected) && !client.Running() {
+			go execRestartCheckpoint(row.check.ID, row.check.GoroutineID, row.check.Where)
+		}
+	}
+}
+
+func (h *exprHistory) plot(w *nucular.Window, style *nstyle.Style) {
+	bounds, out := w.Custom(nstyle.WidgetStateInactive)
+	if out == nil {
+		return
+	}
+	min, max := h.rows[0].num, h.rows[0].num
+	for i := range h.rows {
+		if h.rows[i].num < min {
+			min = h.rows[i].num
+		}
+		if h.rows[i].num > max {
+			max = h.rows[i].num
+		}
+	}
+	if max == min {
+		max = min + 1
+	}
+	point := func(i int) image.Point {
+		x := bounds.X + i*bounds.W/(len(h.rows)-1)
+		y := bounds.Y + bounds.H - int((h.rows[i].num-min)/(max-min)*float64(bounds.H))
+		return image.Point{x, y}
+	}
+	lineColor := linkColor
+	dotSize := int(4 * style.Scaling)
+	for i := range h.rows {
+		p := point(i)
+		if i > 0 {
+			out.StrokeLine(point(i-1), p, 1, lineColor)
+		}
+		c := lineColor
+		if h.rows[i].changed {
+			c = color.RGBA{0xdd, 0x33, 0x33, 0xff}
+		}
+		out.FillRect(rect.Rect{X: p.X - dotSize/2, Y: p.Y - dotSize/2, W: dotSize, H: dotSize}, 0, c)
+	}
+	out.DrawText(rect.Rect{X: bounds.X, Y: bounds.Y, W: bounds.W, H: nucular.FontHeight(style.Font)}, strconv.FormatFloat(max, 'g', -1, 64), style.Font, style.Text.Color)
+}
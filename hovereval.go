@@ -0,0 +1,195 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"image"
+	"strings"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+	"github.com/aarzilli/gdlv/internal/prettyprint"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+
+	"golang.org/x/mobile/event/mouse"
+)
+
+// listingHover is the evaluation of the expression under the mouse in the
+// Listing panel.
+var listingHover struct {
+	fset *token.FileSet
+	src  *sourceFile // parse of the file shown in the Listing panel
+
+	scopeID    int          // value of listingPanel.id when scopeLines was computed
+	scopeLines map[int]bool // lines of the current function, where hovering is possible
+
+	// result of the last call to listingHoverExpr
+	at       *sourceFile
+	line     int
+	col      int
+	lastExpr string
+
+	id   int // value of listingPanel.id when expr was evaluated
+	expr string
+	done bool
+	v    *api.Variable
+}
+
+// hoverExpression returns the expression that should be evaluated when the
+// mouse is over ident: if ident is the selector of a sequence of selectors
+// (for example Get in req.Header.Get) the whole sequence, otherwise the
+// identifier itself. Returns an empty string if the selector is applied to
+// something other than an identifier, evaluating it could have side effects.
+func hoverExpression(fset *token.FileSet, src []byte, ident *ast.Ident, sel *ast.SelectorExpr) string {
+	if sel == nil {
+		return ident.Name
+	}
+	if !selectorSequence(sel) {
+		return ""
+	}
+	start, end := fset.Position(sel.Pos()).Offset, fset.Position(sel.End()).Offset
+	if start < 0 || end > len(src) || start >= end {
+		return ident.Name
+	}
+	return strings.Join(strings.Fields(string(src[start:end])), "")
+}
+
+// listingSource returns the parse of the file shown in the Listing panel,
+// or nil if it can not be parsed.
+func listingSource() (*token.FileSet, *sourceFile) {
+	if listingHover.src == nil || listingHover.src.path != listingPanel.file {
+		listingHover.fset = new(token.FileSet)
+		sf, err := parseSourceFile(listingHover.fset, listingPanel.file)
+		if err != nil {
+			// remember the failure so that we don't parse the file every frame
+			sf = &sourceFile{path: listingPanel.file}
+		}
+		listingHover.src = sf
+	}
+	if listingHover.src.ast == nil {
+		return nil, nil
+	}
+	return listingHover.fset, listingHover.src
+}
+
+// listingHoverExpr returns the expression at line:col of the file shown in
+// the Listing panel. The result is cached, because the mouse stays at the
+// same position for many frames.
+func listingHoverExpr(line, col int) string {
+	fset, sf := listingSource()
+	if sf == nil {
+		return ""
+	}
+	if listingHover.at == sf && listingHover.line == line && listingHover.col == col {
+		return listingHover.lastExpr
+	}
+	expr := findHoverExpr(fset, sf, line, col)
+	listingHover.at, listingHover.line, listingHover.col, listingHover.lastExpr = sf, line, col, expr
+	return expr
+}
+
+func findHoverExpr(fset *token.FileSet, sf *sourceFile, line, col int) string {
+	ident, sel := identAt(fset, sf.ast, line, col)
+	if ident == nil || ident.Name == "_" {
+		return ""
+	}
+	if _, ok := packageQualifier(sf.ast, sel); ok {
+		// a package level declaration of another package, delve can't
+		// evaluate the qualified name
+		return ""
+	}
+	return hoverExpression(fset, sf.src, ident, sel)
+}
+
+// listingInScope returns true if line of the Listing panel belongs to the
+// function of the current frame, identifiers elsewhere can not be evaluated
+// in its scope.
+func listingInScope(line int) bool {
+	if listingHover.scopeLines == nil || listingHover.scopeID != listingPanel.id {
+		listingHover.scopeID, listingHover.scopeLines = listingPanel.id, map[int]bool{}
+		if fset, sf := listingSource(); sf != nil {
+			if i := listingCurrentLine(); i >= 0 {
+				if fn := enclosingFunc(fset, sf.ast, listingPanel.listing[i].lineno); fn != nil {
+					listingHover.scopeLines = funcScopeLines(fset, fn)
+				}
+			}
+		}
+	}
+	return listingHover.scopeLines[line]
+}
+
+// listingHoverValue returns the value of expr in the current scope. The
+// evaluation is started in the background the first time expr is hovered.
+func listingHoverValue(expr string) *api.Variable {
+	if listingHover.id == listingPanel.id && listingHover.expr == expr {
+		if !listingHover.done {
+			return nil
+		}
+		return listingHover.v
+	}
+	listingHover.id, listingHover.expr = listingPanel.id, expr
+	listingHover.done, listingHover.v = false, nil
+	id := listingPanel.id
+	go func() {
+		v, err := client.EvalVariable(currentEvalScope(), expr, ShortLoadConfig)
+		wnd.Lock()
+		defer wnd.Unlock()
+		if listingHover.id != id || listingHover.expr != expr {
+			return
+		}
+		listingHover.done = true
+		if err == nil && v.Unreadable == "" {
+			listingHover.v = v
+		}
+		wnd.Changed()
+	}()
+	return nil
+}
+
+// updateListingHover shows the value of the expression under the mouse at
+// line:col of the Listing panel, clicking on it opens a popup to pin the
+// expression or show its details.
+func updateListingHover(w *nucular.Window, line, col int) {
+	if client.Running() || curThread < 0 || listingPanel.pinnedLoc != nil || !listingInScope(line) {
+		return
+	}
+	expr := listingHoverExpr(line, col)
+	if expr == "" {
+		return
+	}
+	v := listingHoverValue(expr)
+	if v == nil {
+		return
+	}
+	bounds := w.LastWidgetBounds
+	w.Tooltip(expr + " = " + prettyprint.Singleline(v, true, false))
+	if w.Input().Mouse.Clicked(mouse.ButtonLeft, bounds) {
+		openHoverPopup(w.Master(), expr, v, w.Input().Mouse.Pos)
+	}
+}
+
+func openHoverPopup(mw nucular.MasterWindow, expr string, v *api.Variable, pos image.Point) {
+	value := prettyprint.Singleline(v, true, false)
+	details := detailsAvailable(wrapApiVariableSimple(v))
+	mw.PopupOpen(expr, dynamicPopupFlags|nucular.WindowClosable, rect.Rect{pos.X, pos.Y, 400, 700}, true, func(w *nucular.Window) {
+		w.Row(20).Dynamic(1)
+		w.Label(value, "LC")
+		w.Row(20).Dynamic(3)
+		if w.ButtonText("Pin") {
+			addExpression(expr)
+			w.Close()
+		}
+		if details != nil {
+			if w.ButtonText("Details") {
+				details(w.Master(), expr)
+				w.Close()
+			}
+		} else {
+			w.Spacing(1)
+		}
+		if w.ButtonText("Close") {
+			w.Close()
+		}
+	})
+}
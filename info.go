@@ -116,8 +116,19 @@ const (
 type wrappedGoroutine struct {
 	api.Goroutine
 	atBreakpoint bool
+	diff         goroutineDiff
 }
 
+// goroutineDiff describes how a goroutine changed since the previous stop.
+type goroutineDiff uint8
+
+const (
+	goroutineUnchanged goroutineDiff = iota
+	goroutineNew
+	goroutineMoved
+	goroutineGone
+)
+
 var goroutineLocations = []string{currentGoroutineLocation, userGoroutineLocation, goStatementLocation, startLocation}
 var goroutinesPanel = struct {
 	asyncLoad         asyncLoad
@@ -129,10 +140,27 @@ var goroutinesPanel = struct {
 
 	filterEditor nucular.TextEditor
 	invertFilter bool
+
+	grouped    bool
+	groupDepth int
+	groups     []goroutineGroup
+	groupOpen  map[string]bool
+
+	// goroutines loaded during the previous stop, used to find out which
+	// goroutines are new, have exited or changed location
+	prev         map[int]wrappedGoroutine
+	prevMaxID    int
+	prevComplete bool
+	gone         []wrappedGoroutine
+	rotateOnStop bool
+	onlyChanged  bool
+	complete     bool // all goroutines were loaded
 }{
 	goroutineLocation: 1,
 	goroutines:        make([]wrappedGoroutine, 0, 10),
 	limit:             100,
+	groupDepth:        10,
+	groupOpen:         make(map[string]bool),
 }
 
 var stackPanel = struct {
@@ -176,6 +204,10 @@ type stringSlicePanel struct {
 	selected     int
 	interaction  func(p *stringSlicePanel, w *nucular.Window, clicked bool, idx int, bounds rect.Rect)
 	id           int
+
+	// if imageFilter is not nil only the values it contains are shown, see filterByImage
+	imageFilter     map[string]bool
+	imageFilterPath string
 }
 
 var funcsPanel = stringSlicePanel{name: "functions", selected: -1, interaction: funcInteraction}
@@ -205,6 +237,11 @@ func init() {
 	localsPanel.asyncLoad.load = loadLocals
 	disassemblyPanel.asyncLoad.load = loadDisassembly
 	autoCheckpointsPanel.asyncLoad.load = loadAutoCheckpoints
+	memoryPanel.asyncLoad.load = loadMemory
+	librariesPanel.asyncLoad.load = loadLibraries
+	librariesPanel.list.interaction = libraryInteraction
+	goroutineTreePanel.asyncLoad.load = loadGoroutineTree
+	timelinePanel.asyncLoad.load = loadTimeline
 }
 
 func spacefilter(ch rune) bool {
@@ -226,7 +263,7 @@ func loadGoroutines(p *asyncLoad) {
 	if lim == 0 {
 		lim = 100
 	}
-	gs, _, err := client.ListGoroutines(0, lim)
+	gs, nextg, err := client.ListGoroutines(0, lim)
 	if err != nil {
 		p.done(err)
 		return
@@ -246,7 +283,18 @@ func loadGoroutines(p *asyncLoad) {
 
 	sort.Sort(goroutinesByID(gs))
 
+	if goroutinesPanel.rotateOnStop {
+		goroutinesPanel.rotateOnStop = false
+		goroutinesPanel.prev = make(map[int]wrappedGoroutine, len(goroutinesPanel.goroutines))
+		for _, g := range goroutinesPanel.goroutines {
+			goroutinesPanel.prev[g.ID] = g
+		}
+		goroutinesPanel.prevMaxID = goroutinesMaxID(goroutinesPanel.goroutines)
+		goroutinesPanel.prevComplete = goroutinesPanel.complete
+	}
+
 	goroutinesPanel.goroutines = goroutinesPanel.goroutines[:0]
+	goroutinesPanel.complete = nextg < 0
 	goroutinesPanel.id++
 
 	for _, g := range gs {
@@ -258,9 +306,11 @@ func loadGoroutines(p *asyncLoad) {
 			}
 		}
 
-		goroutinesPanel.goroutines = append(goroutinesPanel.goroutines, wrappedGoroutine{*g, atbp})
+		goroutinesPanel.goroutines = append(goroutinesPanel.goroutines, wrappedGoroutine{*g, atbp, goroutineUnchanged})
 	}
 
+	diffGoroutines()
+
 	if LogOutputNice != nil {
 		logf("Goroutines:\n")
 		for i := range goroutinesPanel.goroutines {
@@ -269,9 +319,73 @@ func loadGoroutines(p *asyncLoad) {
 		}
 	}
 
+	goroutinesPanel.groups = goroutinesPanel.groups[:0]
+	if goroutinesPanel.grouped {
+		if err := loadGoroutineGroups(); err != nil {
+			p.done(err)
+			return
+		}
+	}
+
 	p.done(nil)
 }
 
+func goroutinesMaxID(goroutines []wrappedGoroutine) int {
+	if len(goroutines) == 0 {
+		return 0
+	}
+	return goroutines[len(goroutines)-1].ID
+}
+
+// diffGoroutines compares the goroutines loaded for the current stop with
+// the ones loaded for the previous stop. Goroutines that are past the
+// limit of either load can not be classified and are left unchanged.
+func diffGoroutines() {
+	goroutinesPanel.gone = goroutinesPanel.gone[:0]
+	if goroutinesPanel.prev == nil {
+		return
+	}
+
+	maxID := goroutinesMaxID(goroutinesPanel.goroutines)
+	seen := make(map[int]bool, len(goroutinesPanel.goroutines))
+
+	for i := range goroutinesPanel.goroutines {
+		g := &goroutinesPanel.goroutines[i]
+		seen[g.ID] = true
+		old, ok := goroutinesPanel.prev[g.ID]
+		switch {
+		case !ok:
+			if goroutinesPanel.prevComplete || g.ID <= goroutinesPanel.prevMaxID {
+				g.diff = goroutineNew
+			}
+		case old.CurrentLoc.PC != g.CurrentLoc.PC:
+			g.diff = goroutineMoved
+		}
+	}
+
+	for id, old := range goroutinesPanel.prev {
+		if seen[id] || (!goroutinesPanel.complete && id > maxID) {
+			continue
+		}
+		old.diff = goroutineGone
+		old.atBreakpoint = false
+		goroutinesPanel.gone = append(goroutinesPanel.gone, old)
+	}
+	sort.Slice(goroutinesPanel.gone, func(i, j int) bool { return goroutinesPanel.gone[i].ID < goroutinesPanel.gone[j].ID })
+}
+
+func goroutineDiffColor(diff goroutineDiff) (color.RGBA, bool) {
+	switch diff {
+	case goroutineNew:
+		return color.RGBA{0, changedVariableOpacity, 0, changedVariableOpacity}, true
+	case goroutineMoved:
+		return changedVariableColor(), true
+	case goroutineGone:
+		return color.RGBA{changedVariableOpacity / 2, changedVariableOpacity / 2, changedVariableOpacity / 2, changedVariableOpacity}, true
+	}
+	return color.RGBA{}, false
+}
+
 func goroutineGetDisplayLiocation(g *api.Goroutine) api.Location {
 	switch goroutineLocations[goroutinesPanel.goroutineLocation] {
 	default:
@@ -292,18 +406,20 @@ func updateGoroutines(container *nucular.Window) {
 	if w == nil {
 		return
 	}
-	style := container.Master().Style()
-
 	goroutines := goroutinesPanel.goroutines
 
-	w.MenubarBegin()
-	w.Row(20).Static(130, 300)
-	if w.PropertyInt("Limit:", 1, &goroutinesPanel.limit, 1000000000, 1, 1) {
+	reload := func() {
 		go func() {
 			goroutinesPanel.asyncLoad.clear()
 			wnd.Changed()
 		}()
 	}
+
+	w.MenubarBegin()
+	w.Row(20).Static(130, 300, 130, 130)
+	if w.PropertyInt("Limit:", 1, &goroutinesPanel.limit, 1000000000, 1, 1) {
+		reload()
+	}
 	if w := w.Combo(label.T(goroutineLocations[goroutinesPanel.goroutineLocation]), 500, nil); w != nil {
 		w.Row(22).Dynamic(1)
 		for i := range goroutineLocations {
@@ -312,6 +428,15 @@ func updateGoroutines(container *nucular.Window) {
 			}
 		}
 		w.CheckboxText("Only stoppped at breakpoint", &goroutinesPanel.onlyStopped)
+		w.CheckboxText("Only changed since last stop", &goroutinesPanel.onlyChanged)
+	}
+	if w.CheckboxText("Group by stack", &goroutinesPanel.grouped) && goroutinesPanel.grouped {
+		reload()
+	}
+	if goroutinesPanel.grouped {
+		if w.PropertyInt("Frames:", 1, &goroutinesPanel.groupDepth, 100, 1, 1) {
+			reload()
+		}
 	}
 	w.Row(20).Static(100, 0, 100)
 	w.Label("Filter:", "LC")
@@ -341,70 +466,119 @@ func updateGoroutines(container *nucular.Window) {
 		filter = strings.TrimSpace(string(goroutinesPanel.filterEditor.Buffer))
 	}
 
+	if goroutinesPanel.grouped {
+		updateGoroutineGroups(w, filter, d, dthread)
+		return
+	}
+
 	for i := range goroutines {
 		g := &goroutines[i]
-		if goroutinesPanel.onlyStopped && !g.atBreakpoint {
+		if !goroutineMatches(g, filter) {
 			continue
 		}
+		goroutineRow(w, g, d, dthread, 0)
+	}
 
-		if filter != "" {
-			filterMatch := false
-			loc := goroutineGetDisplayLiocation(&g.Goroutine)
-			if strings.Index(loc.File, filter) >= 0 || strings.Index(loc.Function.Name(), filter) >= 0 {
-				filterMatch = true
-			}
-			if goroutinesPanel.invertFilter {
-				filterMatch = !filterMatch
-			}
-			if !filterMatch {
-				continue
-			}
+	for i := range goroutinesPanel.gone {
+		g := &goroutinesPanel.gone[i]
+		if !goroutineMatches(g, filter) {
+			continue
 		}
+		goroutineRow(w, g, d, dthread, 0)
+	}
+}
 
-		rowHeight := posRowHeight
-		if len(g.Labels) > 0 {
-			rowHeight = int((float64(rowHeight) / 2) * 3)
+func goroutineMatches(g *wrappedGoroutine, filter string) bool {
+	if goroutinesPanel.onlyStopped && !g.atBreakpoint {
+		return false
+	}
+	if goroutinesPanel.onlyChanged && g.diff == goroutineUnchanged {
+		return false
+	}
+
+	if filter != "" {
+		filterMatch := false
+		loc := goroutineGetDisplayLiocation(&g.Goroutine)
+		if strings.Index(loc.File, filter) >= 0 || strings.Index(loc.Function.Name(), filter) >= 0 {
+			filterMatch = true
+		}
+		if goroutinesPanel.invertFilter {
+			filterMatch = !filterMatch
 		}
+		if !filterMatch {
+			return false
+		}
+	}
 
-		w.Row(rowHeight).Static()
-		selected := curGid == g.ID
+	return true
+}
 
-		w.LayoutSetWidthScaled(starWidth + style.Text.Padding.X*2)
-		breakpointIcon(w, g.atBreakpoint, true, "CT", style)
+func goroutineRow(w *nucular.Window, g *wrappedGoroutine, d, dthread, indent int) {
+	style := w.Master().Style()
 
-		w.LayoutFitWidth(goroutinesPanel.id, 1)
-		w.SelectableLabel(fmt.Sprintf("%*d", d, g.ID), "LT", &selected)
+	rowHeight := posRowHeight
+	if len(g.Labels) > 0 {
+		rowHeight = int((float64(rowHeight) / 2) * 3)
+	}
 
-		w.LayoutFitWidth(goroutinesPanel.id, 1)
-		if g.ThreadID != 0 {
-			w.SelectableLabel(fmt.Sprintf("%*d", dthread, g.ThreadID), "LT", &selected)
-		} else {
-			w.SelectableLabel(" ", "LT", &selected)
-		}
+	w.Row(rowHeight).Static()
+	selected := curGid == g.ID
 
-		w.LayoutFitWidth(goroutinesPanel.id, 100)
-		loc := formatLocation2(goroutineGetDisplayLiocation(&g.Goroutine))
-		if len(g.Labels) > 0 {
-			loc += fmt.Sprintf("\nLabels: %s", writeGoroutineLabels(g.Labels))
-		}
-		w.SelectableLabel(loc, "LT", &selected)
+	if indent > 0 {
+		w.LayoutSetWidthScaled(indent)
+		w.Spacing(1)
+	}
 
-		if selected && curGid != g.ID && !client.Running() {
-			go func(gid int) {
-				state, err := client.SwitchGoroutine(gid)
-				if err != nil {
-					out := editorWriter{true}
-					fmt.Fprintf(&out, "Could not switch goroutine: %v\n", err)
-				} else {
-					refreshto := refreshToFrameZero
-					if goroutineLocations[goroutinesPanel.goroutineLocation] == userGoroutineLocation {
-						refreshto = refreshToUserFrame
-					}
-					go refreshState(refreshto, clearGoroutineSwitch, state)
-				}
-			}(g.ID)
+	w.LayoutSetWidthScaled(starWidth + style.Text.Padding.X*2)
+	if c, ok := goroutineDiffColor(g.diff); ok {
+		bounds := w.WidgetBounds()
+		bounds.W = w.Bounds.X + w.Bounds.W - bounds.X
+		w.Commands().FillRect(bounds, 0, c)
+	}
+	breakpointIcon(w, g.atBreakpoint, true, "CT", style)
+
+	w.LayoutFitWidth(goroutinesPanel.id, 1)
+	w.SelectableLabel(fmt.Sprintf("%*d", d, g.ID), "LT", &selected)
+
+	w.LayoutFitWidth(goroutinesPanel.id, 1)
+	if g.ThreadID != 0 {
+		w.SelectableLabel(fmt.Sprintf("%*d", dthread, g.ThreadID), "LT", &selected)
+	} else {
+		w.SelectableLabel(" ", "LT", &selected)
+	}
+
+	w.LayoutFitWidth(goroutinesPanel.id, 100)
+	loc := formatLocation2(goroutineGetDisplayLiocation(&g.Goroutine))
+	switch g.diff {
+	case goroutineNew:
+		loc = "(new) " + loc
+	case goroutineMoved:
+		loc = "(moved) " + loc
+	case goroutineGone:
+		loc = "(exited) " + loc
+	}
+	if len(g.Labels) > 0 {
+		loc += fmt.Sprintf("\nLabels: %s", writeGoroutineLabels(g.Labels))
+	}
+	w.SelectableLabel(loc, "LT", &selected)
+
+	if selected && curGid != g.ID && g.diff != goroutineGone && !client.Running() {
+		refreshto := refreshToFrameZero
+		if goroutineLocations[goroutinesPanel.goroutineLocation] == userGoroutineLocation {
+			refreshto = refreshToUserFrame
 		}
+		go switchGoroutine(g.ID, refreshto)
+	}
+}
+
+func switchGoroutine(gid int, refreshto refreshToFrame) {
+	state, err := client.SwitchGoroutine(gid)
+	if err != nil {
+		out := editorWriter{true}
+		fmt.Fprintf(&out, "Could not switch goroutine: %v\n", err)
+		return
 	}
+	go refreshState(refreshto, clearGoroutineSwitch, state)
 }
 
 func writeGoroutineLabels(labels map[string]string) string {
@@ -530,6 +704,7 @@ func updateStacktrace(container *nucular.Window) {
 			selected = true
 		}
 		if selected && clicked && !client.Running() {
+			listingPushHistory()
 			curFrame = i
 			stackPanel.deferID++
 			curDeferredCall = 0
@@ -695,7 +870,31 @@ func updateBreakpoints(container *nucular.Window) {
 		breakpoints = append(breakpoints, anyBreakpoint{&DisabledBreakpoints[i].Bp, false})
 	}
 
-	for _, breakpoint := range breakpoints {
+	groups := make([]string, len(breakpoints))
+	for i := range breakpoints {
+		groups[i] = breakpointGroup(breakpoints[i].ID)
+	}
+	sort.Stable(breakpointsByGroup{breakpoints, groups})
+
+	if !client.Running() {
+		w.Row(varRowHeight).Dynamic(2)
+		for _, exit := range []bool{false, true} {
+			lbl := "Stop on goroutine creation"
+			if exit {
+				lbl = "Stop on goroutine exit"
+			}
+			on := goroutineEventBreakpoint(exit) >= 0
+			if w.CheckboxText(lbl, &on) {
+				go toggleGoroutineEventBreakpoint(exit)
+			}
+		}
+	}
+
+	for i, breakpoint := range breakpoints {
+		if i == 0 || groups[i] != groups[i-1] {
+			breakpointGroupHeader(w, groups[i])
+		}
+
 		oldselectedId := breakpointsPanel.selected
 		selected := breakpointsPanel.selected == breakpoint.ID
 		w.Row(posRowHeight).Static()
@@ -714,8 +913,16 @@ func updateBreakpoints(container *nucular.Window) {
 			name += " "
 		}
 
+		fnname := breakpoint.FunctionName
+		if ev, ok := GoroutineEvents[breakpoint.ID]; ok {
+			fnname = fmt.Sprintf("%s, %s", ev, fnname)
+		}
+		if breakpoint.WatchExpr != "" {
+			fnname = fmt.Sprintf("watch %s", breakpoint.WatchExpr)
+		}
+
 		w.LayoutFitWidth(breakpointsPanel.id, 100)
-		w.SelectableLabel(fmt.Sprintf("%s%s%s (hit count: %d)\nat %s:%d (%#v)", disableMark, name, breakpoint.FunctionName, breakpoint.TotalHitCount, breakpoint.File, breakpoint.Line, breakpoint.Addr), "LT", &selected)
+		w.SelectableLabel(fmt.Sprintf("%s%s%s (%s)\nat %s:%d (%#v)", disableMark, name, fnname, formatHitCount(breakpoint.Breakpoint), breakpoint.File, breakpoint.Line, breakpoint.Addr), "LT", &selected)
 
 		if !breakpoint.enabled {
 			*style = savedStyle
@@ -741,11 +948,123 @@ func updateBreakpoints(container *nucular.Window) {
 			}
 
 			if breakpointsPanel.selected != oldselectedId {
-				listingPanel.pinnedLoc = &api.Location{File: breakpoint.File, Line: breakpoint.Line, PC: breakpoint.Addr}
-				go refreshState(refreshToSameFrame, clearNothing, nil)
+				listingNavigate(&api.Location{File: breakpoint.File, Line: breakpoint.Line, PC: breakpoint.Addr})
+			}
+		}
+	}
+}
+
+type breakpointsByGroup struct {
+	bps    []anyBreakpoint
+	groups []string
+}
+
+func (v breakpointsByGroup) Len() int           { return len(v.bps) }
+func (v breakpointsByGroup) Less(i, j int) bool { return v.groups[i] < v.groups[j] }
+func (v breakpointsByGroup) Swap(i, j int) {
+	v.bps[i], v.bps[j] = v.bps[j], v.bps[i]
+	v.groups[i], v.groups[j] = v.groups[j], v.groups[i]
+}
+
+func breakpointGroupHeader(w *nucular.Window, group string) {
+	if group == "" {
+		return
+	}
+	w.Row(varRowHeight).Static(0, 100, 100)
+	w.Label(fmt.Sprintf("Group %s", group), "LC")
+	if client.Running() {
+		return
+	}
+	if w.ButtonText("Enable all") {
+		go setBreakpointGroupEnabled(group, true)
+	}
+	if w.ButtonText("Disable all") {
+		go setBreakpointGroupEnabled(group, false)
+	}
+}
+
+type breakpointGroupEditor struct {
+	id     int
+	editor nucular.TextEditor
+}
+
+func openBreakpointGroupEditor(mw nucular.MasterWindow, id int) {
+	ed := &breakpointGroupEditor{id: id}
+	ed.editor.Flags = nucular.EditClipboard | nucular.EditSelectable | nucular.EditSigEnter
+	ed.editor.Filter = spacefilter
+	ed.editor.Buffer = []rune(breakpointGroup(id))
+	ed.editor.Active = true
+	mw.PopupOpen(fmt.Sprintf("Group of breakpoint %d", id), dynamicPopupFlags, rect.Rect{100, 100, 400, 700}, true, ed.update)
+}
+
+func (ed *breakpointGroupEditor) update(w *nucular.Window) {
+	w.Row(30).Static(100, 0)
+	w.Label("Group:", "LC")
+	ev := ed.editor.Edit(w)
+
+	if groups := breakpointGroups(); len(groups) > 0 {
+		w.Row(20).Dynamic(1)
+		w.Label("Existing groups:", "LC")
+		for _, group := range groups {
+			w.Row(20).Dynamic(1)
+			if w.ButtonText(group) {
+				ed.editor.Buffer = []rune(group)
+				ed.editor.Cursor = len(ed.editor.Buffer)
 			}
 		}
 	}
+
+	w.Row(20).Static(0, 80, 80)
+	w.Spacing(1)
+	if w.ButtonText("Cancel") {
+		w.Close()
+	}
+	if w.ButtonText("OK") || ev&nucular.EditCommitted != 0 {
+		if !setBreakpointGroup(ed.id, strings.TrimSpace(string(ed.editor.Buffer))) {
+			scrollbackOut := editorWriter{true}
+			fmt.Fprintf(&scrollbackOut, "Could not set group of breakpoint %d\n", ed.id)
+		}
+		breakpointsPanel.id++
+		w.Close()
+	}
+}
+
+func formatHitCount(bp *api.Breakpoint) string {
+	const maxGoroutineHitCounts = 5
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "hit count: %d", bp.TotalHitCount)
+
+	if len(bp.HitCount) > 0 {
+		gids := make([]int, 0, len(bp.HitCount))
+		for k := range bp.HitCount {
+			if gid, err := strconv.Atoi(k); err == nil {
+				gids = append(gids, gid)
+			}
+		}
+		sort.Ints(gids)
+		buf.WriteString(" goroutines:")
+		for i, gid := range gids {
+			if i >= maxGoroutineHitCounts {
+				buf.WriteString(" ...")
+				break
+			}
+			fmt.Fprintf(&buf, " %d:%d", gid, bp.HitCount[strconv.Itoa(gid)])
+		}
+	}
+
+	if bp.HitCond != "" {
+		kind, n := parseHitCond(bp)
+		switch kind {
+		case hitCondNone:
+			fmt.Fprintf(&buf, ", stop when hits %s", bp.HitCond)
+		case hitCondEveryNth:
+			fmt.Fprintf(&buf, ", stop every %d hits", n)
+		default:
+			fmt.Fprintf(&buf, ", stop when %s %d", strings.ToLower(hitCondKinds[kind]), n)
+		}
+	}
+	return buf.String()
 }
 
 func breakpointContextualMenu(w *nucular.Window) {
@@ -778,24 +1097,15 @@ func breakpointContextualMenu(w *nucular.Window) {
 			go enableBreakpoint(breakpoint.Breakpoint)
 		}
 	}
+	if w.MenuItem(label.TA("Group...", "LC")) {
+		openBreakpointGroupEditor(w.Master(), breakpointsPanel.selected)
+	}
 	if w.MenuItem(label.TA("Clear", "LC")) {
 		go execClearBreakpoint(breakpointsPanel.selected)
 	}
 	if w.MenuItem(label.TA("Clear All", "LC")) {
 		go func() {
-			scrollbackOut := editorWriter{true}
-			for _, bp := range breakpointsPanel.breakpoints {
-				if bp.ID < 0 {
-					continue
-				}
-				_, err := client.ClearBreakpoint(bp.ID)
-				if err != nil {
-					fmt.Fprintf(&scrollbackOut, "Could not clear breakpoint %d: %v\n", bp.ID, err)
-				}
-			}
-			FrozenBreakpoints = nil
-			DisabledBreakpoints = nil
-			saveConfiguration()
+			clearAllBreakpoints(&editorWriter{true})
 			refreshState(refreshToSameFrame, clearBreakpoint, nil)
 			wnd.Changed()
 		}()
@@ -859,6 +1169,65 @@ type breakpointEditor struct {
 	bp          *api.Breakpoint
 	printEditor nucular.TextEditor
 	condEditor  nucular.TextEditor
+	hitCondKind int
+	hitCondN    int
+
+	hitCondEdited bool // hit condition was changed, see parseHitCond
+
+	logpoint  bool
+	logEditor nucular.TextEditor
+}
+
+const (
+	hitCondNone = iota
+	hitCondTotalEqual
+	hitCondTotalAtLeast
+	hitCondEveryNth
+	hitCondGoroutineEqual
+)
+
+var hitCondKinds = []string{"Always", "Total hits ==", "Total hits >=", "Every Nth hit", "Goroutine hits =="}
+
+// parseHitCond converts the hit condition of bp to one of the hitCond
+// constants and its argument, conditions that can not be represented are
+// returned as hitCondNone.
+func parseHitCond(bp *api.Breakpoint) (kind, n int) {
+	fields := strings.Fields(bp.HitCond)
+	if len(fields) != 2 {
+		return hitCondNone, 1
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return hitCondNone, 1
+	}
+	switch {
+	case fields[0] == "==" && bp.HitCondPerG:
+		return hitCondGoroutineEqual, n
+	case bp.HitCondPerG:
+		return hitCondNone, 1
+	case fields[0] == "==":
+		return hitCondTotalEqual, n
+	case fields[0] == ">=":
+		return hitCondTotalAtLeast, n
+	case fields[0] == "%":
+		return hitCondEveryNth, n
+	}
+	return hitCondNone, 1
+}
+
+// formatHitCond is the inverse of parseHitCond.
+func formatHitCond(kind, n int) (hitCond string, perG bool) {
+	switch kind {
+	case hitCondTotalEqual:
+		return fmt.Sprintf("== %d", n), false
+	case hitCondTotalAtLeast:
+		return fmt.Sprintf(">= %d", n), false
+	case hitCondEveryNth:
+		return fmt.Sprintf("%% %d", n), false
+	case hitCondGoroutineEqual:
+		return fmt.Sprintf("== %d", n), true
+	}
+	return "", false
 }
 
 func openBreakpointEditor(mw nucular.MasterWindow, bp *api.Breakpoint) {
@@ -873,16 +1242,30 @@ func openBreakpointEditor(mw nucular.MasterWindow, bp *api.Breakpoint) {
 	ed.condEditor.Flags = nucular.EditClipboard | nucular.EditSelectable
 	ed.condEditor.Buffer = []rune(ed.bp.Cond)
 
+	ed.hitCondKind, ed.hitCondN = parseHitCond(bp)
+
+	ed.logEditor.Flags = nucular.EditClipboard | nucular.EditSelectable
+	if format, ok := Logpoints[bp.ID]; ok {
+		ed.logpoint = true
+		ed.logEditor.Buffer = []rune(format)
+	}
+
 	mw.PopupOpen(fmt.Sprintf("Editing breakpoint %d", breakpointsPanel.selected), dynamicPopupFlags, rect.Rect{100, 100, 400, 700}, true, ed.update)
 }
 
 func (bped *breakpointEditor) update(w *nucular.Window) {
-	w.Row(20).Dynamic(2)
+	w.Row(20).Dynamic(3)
 	if w.OptionText("breakpoint", !bped.bp.Tracepoint) {
 		bped.bp.Tracepoint = false
+		bped.logpoint = false
 	}
-	if w.OptionText("tracepoint", bped.bp.Tracepoint) {
+	if w.OptionText("tracepoint", bped.bp.Tracepoint && !bped.logpoint) {
 		bped.bp.Tracepoint = true
+		bped.logpoint = false
+	}
+	if w.OptionText("logpoint", bped.logpoint) {
+		bped.bp.Tracepoint = true
+		bped.logpoint = true
 	}
 
 	w.Row(20).Static(100, 100, 150)
@@ -928,15 +1311,34 @@ func (bped *breakpointEditor) update(w *nucular.Window) {
 		bped.bp.LoadLocals = nil
 	}
 
-	w.Row(20).Dynamic(1)
-	w.Label("Print:", "LC")
-	w.Row(100).Dynamic(1)
-	bped.printEditor.Edit(w)
+	if bped.logpoint {
+		w.Row(20).Dynamic(1)
+		w.Label("Message (use {expr} to print the value of expr):", "LC")
+		w.Row(30).Dynamic(1)
+		bped.logEditor.Edit(w)
+	} else {
+		w.Row(20).Dynamic(1)
+		w.Label("Print:", "LC")
+		w.Row(100).Dynamic(1)
+		bped.printEditor.Edit(w)
+	}
 
 	w.Row(30).Static(100, 0)
 	w.Label("Condition:", "LC")
 	bped.condEditor.Edit(w)
 
+	w.Row(20).Static(100, 150, 0)
+	w.Label("Stop:", "LC")
+	if kind := w.ComboSimple(hitCondKinds, bped.hitCondKind, 20); kind != bped.hitCondKind {
+		bped.hitCondKind = kind
+		bped.hitCondEdited = true
+	}
+	if bped.hitCondKind != hitCondNone {
+		if w.PropertyInt("N:", 1, &bped.hitCondN, 1<<30, 1, 1) {
+			bped.hitCondEdited = true
+		}
+	}
+
 	w.Row(20).Static(0, 80, 80)
 	w.Spacing(1)
 	if w.ButtonText("Cancel") {
@@ -945,13 +1347,27 @@ func (bped *breakpointEditor) update(w *nucular.Window) {
 	}
 	if w.ButtonText("OK") {
 		bped.bp.Cond = string(bped.condEditor.Buffer)
+		if bped.hitCondEdited {
+			bped.bp.HitCond, bped.bp.HitCondPerG = formatHitCond(bped.hitCondKind, bped.hitCondN)
+		}
 		bped.bp.Variables = bped.bp.Variables[:0]
-		for _, p := range strings.Split(string(bped.printEditor.Buffer), "\n") {
-			if p == "" {
-				continue
+		format := ""
+		if bped.logpoint {
+			format = string(bped.logEditor.Buffer)
+			if err := logpointBreakpoint(bped.bp, format); err != nil {
+				scrollbackOut := editorWriter{true}
+				fmt.Fprintf(&scrollbackOut, "Could not amend breakpoint: %v\n", err)
+				return
+			}
+		} else {
+			for _, p := range strings.Split(string(bped.printEditor.Buffer), "\n") {
+				if p == "" {
+					continue
+				}
+				bped.bp.Variables = append(bped.bp.Variables, p)
 			}
-			bped.bp.Variables = append(bped.bp.Variables, p)
 		}
+		setLogpoint(bped.bp.ID, format)
 		go bped.amendBreakpoint()
 		w.Close()
 	}
@@ -962,6 +1378,11 @@ func (bped *breakpointEditor) amendBreakpoint() {
 	if err != nil {
 		scrollbackOut := editorWriter{true}
 		fmt.Fprintf(&scrollbackOut, "Could not amend breakpoint: %v\n", err)
+	} else if bped.bp.HitCond != "" {
+		if bp, err := client.GetBreakpoint(bped.bp.ID); err == nil && bp.HitCond == "" {
+			scrollbackOut := editorWriter{true}
+			fmt.Fprintf(&scrollbackOut, "Hit count conditions are not supported by this version of delve\n")
+		}
 	}
 	refreshState(refreshToSameFrame, clearBreakpoint, nil)
 	autoCheckpointsReloadVars()
@@ -1118,6 +1539,14 @@ func (p *stringSlicePanel) update(container *nucular.Window) {
 	w.Row(20).Static(90, 0)
 	w.Label("Filter:", "LC")
 	p.filterEditor.Edit(w)
+	if p.imageFilter != nil {
+		w.Row(20).Static(90, 0, 100)
+		w.Label("Image:", "LC")
+		w.Label(p.imageFilterPath, "LC")
+		if w.ButtonText("Show all") {
+			clearImageFilter()
+		}
+	}
 	w.MenubarEnd()
 
 	filter := string(p.filterEditor.Buffer)
@@ -1126,6 +1555,9 @@ func (p *stringSlicePanel) update(container *nucular.Window) {
 		if strings.Index(value, filter) < 0 {
 			continue
 		}
+		if p.imageFilter != nil && !p.imageFilter[value] {
+			continue
+		}
 		w.Row(20).Static()
 		selected := i == p.selected
 		w.LayoutFitWidth(p.id, 100)
@@ -1152,8 +1584,7 @@ func funcInteraction(p *stringSlicePanel, w *nucular.Window, clicked bool, idx i
 	if clicked {
 		locs, err := client.FindLocation(currentEvalScope(), p.slice[p.selected], true)
 		if err == nil && len(locs) == 1 {
-			listingPanel.pinnedLoc = &locs[0]
-			go refreshState(refreshToSameFrame, clearNothing, nil)
+			listingNavigate(&locs[0])
 		}
 	}
 	if w := w.ContextualOpen(0, image.Point{}, bounds, nil); w != nil {
@@ -1174,8 +1605,7 @@ func functionListSetBreakpoint(name string) {
 
 func sourceInteraction(p *stringSlicePanel, w *nucular.Window, clicked bool, idx int, bounds rect.Rect) {
 	if clicked {
-		listingPanel.pinnedLoc = &api.Location{File: p.slice[p.selected], Line: 1}
-		go refreshState(refreshToSameFrame, clearNothing, nil)
+		listingNavigate(&api.Location{File: p.slice[p.selected], Line: 1})
 	}
 	if w := w.ContextualOpen(0, image.Point{}, bounds, nil); w != nil {
 		w.Row(20).Dynamic(1)
@@ -1244,8 +1674,12 @@ func updateListingPanel(container *nucular.Window) {
 
 	container.Data = nil
 
+	listingTabsToolbar(container)
 	listingToolbar(container)
 
+	listingFind.keys(container, true)
+	listingFind.update(container, len(listingPanel.listing), listingLine, listingCurrentLine(), listingLineno)
+
 	const lineheight = 14
 
 	container.Row(0).Dynamic(1)
@@ -1259,10 +1693,12 @@ func updateListingPanel(container *nucular.Window) {
 	arroww := arrowWidth + style.Text.Padding.X*2
 	starw := starWidth + style.Text.Padding.X*2
 
-	if !listingPanel.recenterListing {
+	if !listingPanel.recenterListing && !listingFind.recenter {
 		gl.SkipToVisible(lineheight)
 	}
 
+	showInline := inlineValuesReady()
+
 	for gl.Next() {
 		listp.Row(lineheight).Static()
 		line := listingPanel.listing[gl.Index()]
@@ -1282,6 +1718,23 @@ func updateListingPanel(container *nucular.Window) {
 			}
 		}
 
+		if gl.Index() == listingFind.cur || listingFind.matches(line.text) {
+			rowbounds := listp.WidgetBounds()
+			rowbounds.X = listp.Bounds.X
+			rowbounds.W = listp.Bounds.W
+			c := findMatchColor
+			if gl.Index() == listingFind.cur {
+				c = style.Selectable.PressedActive.Data.Color
+				darken(&c)
+			}
+			listp.Commands().FillRect(rowbounds, 0, c)
+
+			if gl.Index() == listingFind.cur && listingFind.recenter && !listingPanel.recenterListing {
+				gl.Center()
+				listingFind.recenter = false
+			}
+		}
+
 		listp.LayoutSetWidth(starw)
 		breakpointIcon(listp, line.bp != nil, line.bpenabled, "CC", style)
 		bpbounds := listp.LastWidgetBounds
@@ -1300,9 +1753,19 @@ func updateListingPanel(container *nucular.Window) {
 		listp.LayoutFitWidth(listingPanel.id, 1)
 		listp.Label(line.idx, "LC")
 		listp.LayoutFitWidth(listingPanel.id, 100)
-		listp.Label(line.text, "LC")
+		highlightedLabel(listp, line.text, line.spans)
 		textbounds := listp.LastWidgetBounds
 
+		if showInline {
+			drawInlineValues(listp, line, textbounds)
+		}
+
+		if listp.Input().Mouse.HoveringRect(textbounds) {
+			colno := (listp.Input().Mouse.Pos.X - textbounds.X) / zeroWidth
+			_, colno = expandTabsEx(line.textWithTabs, colno)
+			updateListingHover(listp, line.lineno, colno+1)
+		}
+
 		if centerline && listingPanel.recenterListing {
 			listingPanel.recenterListing = false
 			gl.Center()
@@ -1331,6 +1794,8 @@ func updateListingPanel(container *nucular.Window) {
 				_, colno = expandTabsEx(line.textWithTabs, colno)
 				colno++
 				listingPanel.stepIntoInfo.Config(listingPanel.file, line.lineno, colno)
+				listingPanel.identLine, listingPanel.identCol = line.lineno, colno
+				listingPanel.ident = identNameAt(line.textWithTabs, colno)
 			}
 
 			if w := listp.ContextualOpen(0, image.Point{}, ctxtbounds, nil); w != nil {
@@ -1358,6 +1823,18 @@ func updateListingPanel(container *nucular.Window) {
 					if w.MenuItem(label.TA("Set breakpoint", "LC")) {
 						go listingSetBreakpoint(listingPanel.file, line.lineno)
 					}
+					if w.MenuItem(label.TA("Set logpoint...", "LC")) {
+						openLogpointEditor(w.Master(), listingPanel.file, line.lineno)
+					}
+				}
+				if listingPanel.ident != "" && listingPanel.identLine == line.lineno {
+					file, identLine, identCol := listingPanel.file, listingPanel.identLine, listingPanel.identCol
+					if w.MenuItem(label.TA(fmt.Sprintf("Go to definition of %s", listingPanel.ident), "LC")) {
+						go goToDefinition(&editorWriter{true}, file, identLine, identCol)
+					}
+					if w.MenuItem(label.TA(fmt.Sprintf("Find references to %s in package", listingPanel.ident), "LC")) {
+						go printReferences(&editorWriter{true}, file, identLine, identCol)
+					}
 				}
 				if isCurrentLine {
 					if listingPanel.stepIntoInfo.Valid {
@@ -1384,7 +1861,9 @@ func updateListingPanel(container *nucular.Window) {
 			if line.bp.Cond != "" {
 				fmt.Fprintf(&bpinfo, "when %s ", line.bp.Cond)
 			}
-			if len(line.bp.Variables) > 0 {
+			if format, ok := Logpoints[line.bp.ID]; ok {
+				fmt.Fprintf(&bpinfo, "log %q", format)
+			} else if len(line.bp.Variables) > 0 {
 				fmt.Fprintf(&bpinfo, "print %s", strings.Join(line.bp.Variables, "; "))
 			}
 			listp.LabelColored(bpinfo.String(), "LC", bpcolor)
@@ -1398,11 +1877,14 @@ func listingSetBreakpoint(file string, line int) {
 }
 
 func updateDisassemblyPanel(container *nucular.Window) {
+	disassemblyFind.keys(container, false)
 	container = disassemblyPanel.asyncLoad.showRequest(container)
 	if container == nil {
 		return
 	}
 
+	disassemblyFind.update(container, len(listingPanel.text), disassemblyLine, disassemblyCurrentLine(), nil)
+
 	const lineheight = 14
 
 	container.Row(0).Dynamic(1)
@@ -1467,6 +1949,26 @@ func updateDisassemblyPanel(container *nucular.Window) {
 			cmds.FillRect(rowbounds, 0, style.Selectable.PressedActive.Data.Color)
 		}
 
+		if disassemblyFind.matches(disassemblyLine(gl.Index())) && gl.Index() != disassemblyFind.cur {
+			rowbounds := listp.WidgetBounds()
+			rowbounds.X = listp.Bounds.X
+			rowbounds.W = listp.Bounds.W
+			listp.Commands().FillRect(rowbounds, 0, findMatchColor)
+		}
+
+		if gl.Index() == disassemblyFind.cur {
+			if disassemblyFind.recenter {
+				disassemblyFind.recenter = false
+				gl.Center()
+			}
+			rowbounds := listp.WidgetBounds()
+			rowbounds.X = listp.Bounds.X
+			rowbounds.W = listp.Bounds.W
+			c := style.Selectable.PressedActive.Data.Color
+			darken(&c)
+			listp.Commands().FillRect(rowbounds, 0, c)
+		}
+
 		if gl.Index() == listingPanel.disassHoverIdx || gl.Index() == listingPanel.disassHoverClickIdx {
 			if listingPanel.centerOnDisassHover {
 				listingPanel.centerOnDisassHover = false
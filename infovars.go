@@ -495,6 +495,25 @@ func showExprMenu(parentw *nucular.Window, exprMenuIdx int, v *Variable, clipb [
 		clipboard.Set(string(clipb))
 	}
 
+	if v.Expression != "" || v.Addr != 0 {
+		if w.MenuItem(label.TA("View memory", "LC")) {
+			if v.Expression != "" {
+				openMemoryWindow(v.Expression)
+			} else {
+				openMemoryWindow(fmt.Sprintf("%#x", v.Addr))
+			}
+		}
+	}
+
+	if v.Expression != "" && v.Addr != 0 {
+		if w.MenuItem(label.TA("Watch for writes", "LC")) {
+			doCommand(fmt.Sprintf("watch %s", v.Expression))
+		}
+		if client.Recorded() && w.MenuItem(label.TA("Find last write", "LC")) {
+			doCommand(fmt.Sprintf("rev-watch %s", v.Expression))
+		}
+	}
+
 	if exprMenuIdx >= 0 && exprMenuIdx < len(localsPanel.expressions) {
 		pinned := exprIsScoped(localsPanel.expressions[exprMenuIdx].Expr)
 		if w.MenuItem(label.TA("Edit expression", "LC")) {
@@ -515,6 +534,9 @@ func showExprMenu(parentw *nucular.Window, exprMenuIdx int, v *Variable, clipb [
 			localsPanel.expressions = localsPanel.expressions[:len(localsPanel.expressions)-1]
 			localsPanel.v = localsPanel.v[:len(localsPanel.v)-1]
 		}
+		if client.Recorded() && w.MenuItem(label.TA("History", "LC")) {
+			openExprHistoryWindow(w.Master(), localsPanel.expressions[exprMenuIdx].Expr)
+		}
 		if w.MenuItem(label.TA("Load parameters...", "LC")) {
 			w.Master().PopupOpen(fmt.Sprintf("Load parameters for %s", localsPanel.expressions[exprMenuIdx].Expr), dynamicPopupFlags, rect.Rect{100, 100, 400, 700}, true, configureLoadParameters(exprMenuIdx))
 		}
@@ -547,8 +569,7 @@ func showExprMenu(parentw *nucular.Window, exprMenuIdx int, v *Variable, clipb [
 		if w.MenuItem(label.TA("Go to definition", "LC")) {
 			locs, err := client.FindLocation(currentEvalScope(), fmt.Sprintf("*%#x", v.Base), true)
 			if err == nil && len(locs) == 1 {
-				listingPanel.pinnedLoc = &locs[0]
-				go refreshState(refreshToSameFrame, clearNothing, nil)
+				listingNavigate(&locs[0])
 			}
 		}
 	}
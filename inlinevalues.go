@@ -0,0 +1,184 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"unicode/utf8"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+)
+
+const maxInlineValueLen = 60
+
+// inlineValues are the names of the local variables used on each line of
+// the current function, their values are shown at the end of the lines of
+// the Listing panel.
+var inlineValues struct {
+	id    int // value of listingPanel.id when names was computed
+	names map[int][]string
+}
+
+// enclosingFunc returns the innermost function declaration or function
+// literal of f containing line, or nil.
+func enclosingFunc(fset *token.FileSet, f *ast.File, line int) ast.Node {
+	contains := func(n ast.Node) bool {
+		return fset.Position(n.Pos()).Line <= line && line <= fset.Position(n.End()).Line
+	}
+
+	var fn ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body == nil || !contains(n) {
+				return false
+			}
+			fn = n
+		case *ast.FuncLit:
+			if !contains(n) {
+				return false
+			}
+			fn = n
+		}
+		return true
+	})
+	return fn
+}
+
+// funcScopeLines returns the lines of fn where its local variables are in
+// scope: all its lines except the ones of nested function literals.
+func funcScopeLines(fset *token.FileSet, fn ast.Node) map[int]bool {
+	r := map[int]bool{}
+	for l := fset.Position(fn.Pos()).Line; l <= fset.Position(fn.End()).Line; l++ {
+		r[l] = true
+	}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if n, ok := n.(*ast.FuncLit); ok && n != fn {
+			// the first and last lines can also belong to fn
+			for l := fset.Position(n.Pos()).Line + 1; l < fset.Position(n.End()).Line; l++ {
+				delete(r, l)
+			}
+			return false
+		}
+		return true
+	})
+	return r
+}
+
+// inlineValueNames returns, for each line of the innermost function
+// containing line, up to line, the names of the local variables used on it
+// in order of appearance.
+func inlineValueNames(fset *token.FileSet, f *ast.File, line int) map[int][]string {
+	fn := enclosingFunc(fset, f, line)
+	if fn == nil {
+		return nil
+	}
+
+	r := map[int][]string{}
+	selectors := map[*ast.Ident]bool{}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// variables of a nested closure are not in scope
+			return n == fn
+		case *ast.SelectorExpr:
+			selectors[n.Sel] = true
+		case *ast.Ident:
+			if n.Name == "_" || selectors[n] || n.Obj == nil || n.Obj.Kind != ast.Var || f.Scope.Lookup(n.Name) == n.Obj {
+				break
+			}
+			l := fset.Position(n.Pos()).Line
+			if l > line {
+				break
+			}
+			for _, name := range r[l] {
+				if name == n.Name {
+					return true
+				}
+			}
+			r[l] = append(r[l], n.Name)
+		}
+		return true
+	})
+	return r
+}
+
+// inlineLocal returns the local variable called name visible at line.
+func inlineLocal(name string, line int) *Variable {
+	var r *Variable
+	for _, v := range localsPanel.locals {
+		if v.Name == name && int(v.DeclLine) <= line && (r == nil || v.DeclLine > r.DeclLine) {
+			r = v
+		}
+	}
+	return r
+}
+
+// inlineValuesReady returns true if the values of local variables can be
+// shown in the Listing panel, loading them if necessary.
+func inlineValuesReady() bool {
+	if !listingPanel.inlineValues || client.Running() || curThread < 0 || listingPanel.pinnedLoc != nil {
+		return false
+	}
+	if inlineValues.id != listingPanel.id {
+		inlineValues.id, inlineValues.names = listingPanel.id, nil
+		if fset, sf := listingSource(); sf != nil {
+			if i := listingCurrentLine(); i >= 0 {
+				inlineValues.names = inlineValueNames(fset, sf.ast, listingPanel.listing[i].lineno)
+			}
+		}
+	}
+	if inlineValues.names == nil {
+		return false
+	}
+
+	l := &localsPanel.asyncLoad
+	l.startLoad()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loaded && l.err == nil
+}
+
+// drawInlineValues draws the values of the local variables used on line
+// after its text, whose bounds are textbounds.
+func drawInlineValues(w *nucular.Window, line listline, textbounds rect.Rect) {
+	names := inlineValues.names[line.lineno]
+	if len(names) == 0 {
+		return
+	}
+	style := w.Master().Style()
+	c := style.Text.Color
+	darken(&c)
+
+	cmds := w.Commands()
+	r := textbounds
+	r.X += style.Text.Padding.X + nucular.FontWidth(style.Font, line.text) + 4*zeroWidth
+	r.W = w.Bounds.X + w.Bounds.W - r.X
+	sep := ""
+	for _, name := range names {
+		v := inlineLocal(name, line.lineno)
+		if v == nil {
+			continue
+		}
+		value := v.SinglelineString(false, false)
+		if len(value) > maxInlineValueLen {
+			n := maxInlineValueLen
+			for n > 0 && !utf8.RuneStart(value[n]) {
+				n--
+			}
+			value = value[:n] + "..."
+		}
+		if sep != "" {
+			cmds.DrawText(r, sep, style.Font, c)
+			r.X += nucular.FontWidth(style.Font, sep)
+		}
+		str := name + " = " + value
+		width := nucular.FontWidth(style.Font, str)
+		if v.changed {
+			cmds.FillRect(rect.Rect{X: r.X, Y: r.Y, W: width, H: r.H}, 0, changedVariableColor())
+		}
+		cmds.DrawText(r, str, style.Font, c)
+		r.X += width
+		sep = ", "
+	}
+}
@@ -63,6 +63,17 @@ type Breakpoint struct {
 
 	// Breakpoint condition
 	Cond string
+	// Breakpoint hit count condition, an operator (==, !=, >, >=, <, <=
+	// or %) followed by an integer
+	HitCond string `json:"hitCond,omitempty"`
+	// HitCondPerG applies HitCond to the hit count of the goroutine
+	// instead of the total hit count
+	HitCondPerG bool `json:"hitCondPerG,omitempty"`
+
+	// WatchExpr is the expression used to create this watchpoint
+	WatchExpr string
+	// WatchType is the type of accesses that trigger this watchpoint
+	WatchType WatchType
 
 	// Tracepoint flag, signifying this is a tracepoint.
 	Tracepoint bool `json:"continue"`
@@ -85,6 +96,15 @@ type Breakpoint struct {
 	TotalHitCount uint64 `json:"totalHitCount"`
 }
 
+// WatchType is the type of watchpoint, a combination of WatchRead and
+// WatchWrite.
+type WatchType uint8
+
+const (
+	WatchRead WatchType = 1 << iota
+	WatchWrite
+)
+
 // ValidBreakpointName returns an error if
 // the name to be chosen for a breakpoint is invalid.
 // The name can not be just a number, and must contain a series
@@ -313,6 +333,12 @@ type Goroutine struct {
 	Unreadable string `json:"unreadable"`
 	// Goroutine's pprof labels
 	Labels map[string]string `json:"labels,omitempty"`
+	// Time the goroutine has been waiting since (only set by servers that
+	// support it)
+	WaitSince int64 `json:"waitSince"`
+	// Reason the goroutine is waiting, see runtime.waitReason (only set by
+	// servers that support it)
+	WaitReason int64 `json:"waitReason"`
 }
 
 // DebuggerCommand is a command which changes the debugger's execution state.
@@ -111,6 +111,16 @@ type CreateBreakpointOut struct {
 	Breakpoint api.Breakpoint
 }
 
+type CreateWatchpointIn struct {
+	Scope api.EvalScope
+	Expr  string
+	Type  api.WatchType
+}
+
+type CreateWatchpointOut struct {
+	*api.Breakpoint
+}
+
 type ClearBreakpointIn struct {
 	Id   int
 	Name string
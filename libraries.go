@@ -0,0 +1,192 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/clipboard"
+	"github.com/aarzilli/nucular/label"
+	"github.com/aarzilli/nucular/rect"
+)
+
+var librariesPanel = struct {
+	asyncLoad asyncLoad
+	images    []api.Image
+	list      stringSlicePanel
+}{
+	list: stringSlicePanel{name: "libraries", selected: -1},
+}
+
+// symbolLocations caches the entry point of every function and the address
+// range of every image, it is used to determine which image each function
+// and source file belongs to.
+var symbolLocations = struct {
+	mu     sync.Mutex
+	id     int // value of funcsPanel.id when locs was computed
+	images int // number of images loaded when loadProgramInfo last ran
+	locs   []api.Location
+	ranges map[string]imageRange
+}{}
+
+type imageRange struct {
+	start, end uint64
+}
+
+func loadLibraries(p *asyncLoad) {
+	images, err := client.ListDynamicLibraries()
+	if err != nil {
+		p.done(err)
+		return
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Address < images[j].Address })
+
+	librariesPanel.images = images
+	librariesPanel.list.slice = make([]string, len(images))
+	d := 0
+	for _, img := range images {
+		if n := hexdigits(img.Address); n > d {
+			d = n
+		}
+	}
+	for i, img := range images {
+		librariesPanel.list.slice[i] = fmt.Sprintf("%#0*x %s", d+2, img.Address, img.Path)
+	}
+	librariesPanel.list.selected = -1
+	librariesPanel.list.id++
+	p.done(nil)
+}
+
+func updateLibraries(container *nucular.Window) {
+	w := librariesPanel.asyncLoad.showRequest(container)
+	if w == nil {
+		return
+	}
+	librariesPanel.list.update(w)
+}
+
+func libraryInteraction(p *stringSlicePanel, w *nucular.Window, clicked bool, idx int, bounds rect.Rect) {
+	if clicked && !client.Running() {
+		go filterByImage(librariesPanel.images, idx)
+	}
+	if w := w.ContextualOpen(0, image.Point{}, bounds, nil); w != nil {
+		w.Row(20).Dynamic(1)
+		if w.MenuItem(label.TA("Filter functions and sources", "LC")) && !client.Running() {
+			go filterByImage(librariesPanel.images, idx)
+		}
+		if w.MenuItem(label.TA("Remove filter", "LC")) {
+			clearImageFilter()
+		}
+		if w.MenuItem(label.TA("Copy to clipboard", "LC")) {
+			clipboard.Set(librariesPanel.images[idx].Path)
+		}
+	}
+}
+
+// imageAddressRange returns the range of addresses spanned by the
+// executable segments of images[idx]. If the image can not be read as an
+// ELF file the range ends at the start of the next image, images must be
+// sorted by address.
+func imageAddressRange(images []api.Image, idx int) imageRange {
+	img := images[idx]
+	if r, ok := symbolLocations.ranges[img.Path]; ok {
+		return r
+	}
+	r := imageRange{img.Address, ^uint64(0)}
+	if idx+1 < len(images) {
+		r.end = images[idx+1].Address
+	}
+	if f, err := elf.Open(img.Path); err == nil {
+		found := false
+		for _, prog := range f.Progs {
+			if prog.Type != elf.PT_LOAD || prog.Flags&elf.PF_X == 0 {
+				continue
+			}
+			// img.Address is the difference between the load address and the
+			// link address of the image
+			start, end := img.Address+prog.Vaddr, img.Address+prog.Vaddr+prog.Memsz
+			if !found || start < r.start {
+				r.start = start
+			}
+			if !found || end > r.end {
+				r.end = end
+			}
+			found = true
+		}
+		f.Close()
+	}
+	if symbolLocations.ranges == nil {
+		symbolLocations.ranges = make(map[string]imageRange)
+	}
+	symbolLocations.ranges[img.Path] = r
+	return r
+}
+
+// filterByImage restricts the functions and sources panels to the symbols
+// defined by images[idx], images must be sorted by address.
+func filterByImage(images []api.Image, idx int) {
+	if idx < 0 || idx >= len(images) {
+		return
+	}
+
+	symbolLocations.mu.Lock()
+	stale := symbolLocations.images != len(images)
+	symbolLocations.mu.Unlock()
+	if stale {
+		// new images were loaded (for example by plugin.Open), the list of
+		// functions and sources must be refreshed
+		loadProgramInfo(ioutil.Discard)
+	}
+
+	wnd.Lock()
+	id := funcsPanel.id
+	wnd.Unlock()
+
+	symbolLocations.mu.Lock()
+	defer symbolLocations.mu.Unlock()
+
+	if symbolLocations.locs == nil || symbolLocations.id != id {
+		// a regular expression location returns the entry point of all
+		// matching functions with a single request
+		locs, err := client.FindLocation(currentEvalScope(), "/./", false)
+		if err != nil {
+			fmt.Fprintf(&editorWriter{true}, "Could not read function addresses: %v\n", err)
+			return
+		}
+		symbolLocations.locs = locs
+		symbolLocations.id = id
+	}
+
+	r := imageAddressRange(images, idx)
+	funcs := make(map[string]bool)
+	files := make(map[string]bool)
+	for _, loc := range symbolLocations.locs {
+		if loc.Function != nil && loc.PC >= r.start && loc.PC < r.end {
+			funcs[loc.Function.Name()] = true
+			files[loc.File] = true
+		}
+	}
+
+	wnd.Lock()
+	funcsPanel.setImageFilter(images[idx].Path, funcs)
+	sourcesPanel.setImageFilter(images[idx].Path, files)
+	wnd.Unlock()
+	wnd.Changed()
+}
+
+func clearImageFilter() {
+	funcsPanel.setImageFilter("", nil)
+	sourcesPanel.setImageFilter("", nil)
+}
+
+func (p *stringSlicePanel) setImageFilter(path string, filter map[string]bool) {
+	p.imageFilterPath = path
+	p.imageFilter = filter
+	p.selected = -1
+}
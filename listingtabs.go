@@ -0,0 +1,206 @@
+package main
+
+import (
+	"image"
+	"path/filepath"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/label"
+
+	"golang.org/x/mobile/event/mouse"
+)
+
+// listingTab is a file open in the Listing panel.
+type listingTab struct {
+	file   string
+	line   int  // last line shown
+	pinned bool // stepping does not switch away from this tab
+}
+
+const (
+	maxListingTabs    = 12
+	maxListingHistory = 100
+)
+
+// listingOpenTab selects the tab for file, creating it if it doesn't exist.
+func listingOpenTab(file string, line int) {
+	if file == "" || file == "<autogenerated>" {
+		return
+	}
+	for i := range listingPanel.tabs {
+		if listingPanel.tabs[i].file == file {
+			listingPanel.curTab = i
+			listingPanel.tabs[i].line = line
+			return
+		}
+	}
+	if len(listingPanel.tabs) >= maxListingTabs {
+		// discard the first tab that isn't pinned
+		for i := range listingPanel.tabs {
+			if !listingPanel.tabs[i].pinned {
+				listingPanel.tabs = append(listingPanel.tabs[:i], listingPanel.tabs[i+1:]...)
+				break
+			}
+		}
+	}
+	listingPanel.tabs = append(listingPanel.tabs, listingTab{file: file, line: line})
+	listingPanel.curTab = len(listingPanel.tabs) - 1
+}
+
+// pinnedTabLocation returns the location of the current tab if it is pinned
+// and it isn't showing file.
+func pinnedTabLocation(file string) *api.Location {
+	if listingPanel.curTab < 0 || listingPanel.curTab >= len(listingPanel.tabs) {
+		return nil
+	}
+	tab := listingPanel.tabs[listingPanel.curTab]
+	if !tab.pinned || tab.file == file {
+		return nil
+	}
+	return &api.Location{File: tab.file, Line: tab.line}
+}
+
+// listingPosition returns the location currently shown by the Listing panel.
+func listingPosition() *api.Location {
+	if listingPanel.file == "" {
+		return nil
+	}
+	if listingPanel.pinnedLoc != nil {
+		return &api.Location{File: listingPanel.pinnedLoc.File, Line: listingPanel.pinnedLoc.Line}
+	}
+	line := 0
+	if i := listingCurrentLine(); i >= 0 {
+		line = listingPanel.listing[i].lineno
+	}
+	return &api.Location{File: listingPanel.file, Line: line}
+}
+
+// listingPushHistory saves the location currently shown by the Listing
+// panel in the navigation history.
+func listingPushHistory() {
+	loc := listingPosition()
+	if loc == nil {
+		return
+	}
+	listingPanel.back = pushLocation(listingPanel.back, *loc)
+	listingPanel.forward = listingPanel.forward[:0]
+}
+
+func pushLocation(v []api.Location, loc api.Location) []api.Location {
+	if len(v) > 0 && v[len(v)-1].File == loc.File && v[len(v)-1].Line == loc.Line {
+		return v
+	}
+	if len(v) >= maxListingHistory {
+		v = append(v[:0], v[1:]...)
+	}
+	return append(v, loc)
+}
+
+// listingNavigate shows loc in the Listing panel, saving the current
+// location in the navigation history.
+func listingNavigate(loc *api.Location) {
+	listingPushHistory()
+	listingPanel.pinnedLoc = loc
+	go refreshState(refreshToSameFrame, clearNothing, nil)
+}
+
+// listingBack moves backward (or forward) in the navigation history.
+func listingBack(forward bool) {
+	from, to := &listingPanel.back, &listingPanel.forward
+	if forward {
+		from, to = to, from
+	}
+	if len(*from) == 0 {
+		return
+	}
+	loc := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	if cur := listingPosition(); cur != nil {
+		*to = pushLocation(*to, *cur)
+	}
+	listingPanel.pinnedLoc = &loc
+	go refreshState(refreshToSameFrame, clearNothing, nil)
+}
+
+// listingBackToFrame unpins the current tab and shows the current frame.
+func listingBackToFrame() {
+	if listingPanel.curTab >= 0 && listingPanel.curTab < len(listingPanel.tabs) {
+		listingPanel.tabs[listingPanel.curTab].pinned = false
+	}
+	listingPanel.pinnedLoc = nil
+	go refreshState(refreshToSameFrame, clearNothing, nil)
+}
+
+func listingCloseTab(i int) {
+	listingPanel.tabs = append(listingPanel.tabs[:i], listingPanel.tabs[i+1:]...)
+	if i != listingPanel.curTab {
+		if i < listingPanel.curTab {
+			listingPanel.curTab--
+		}
+		return
+	}
+	listingPanel.curTab = -1
+	listingBackToFrame()
+}
+
+func listingTabsToolbar(w *nucular.Window) {
+	if len(listingPanel.tabs) == 0 {
+		return
+	}
+	style := w.Master().Style()
+	w.Row(headerRow).Static()
+
+	w.LayoutSetWidth(30)
+	if w.ButtonText("<") {
+		listingBack(false)
+	}
+	w.LayoutSetWidth(30)
+	if w.ButtonText(">") {
+		listingBack(true)
+	}
+
+	closeTab := -1
+	for i := range listingPanel.tabs {
+		tab := &listingPanel.tabs[i]
+		name := filepath.Base(tab.file)
+		if tab.pinned {
+			name = "* " + name
+		}
+		w.LayoutSetWidthScaled(nucular.FontWidth(style.Font, name) + style.Selectable.Padding.X*2 + style.Text.Padding.X*2)
+		selected := i == listingPanel.curTab
+		if w.SelectableLabel(name, "CC", &selected) && i != listingPanel.curTab {
+			listingNavigate(&api.Location{File: tab.file, Line: tab.line})
+		}
+		bounds := w.LastWidgetBounds
+		if w.Input().Mouse.HoveringRect(bounds) {
+			w.Tooltip(tab.file)
+		}
+		if w.Input().Mouse.Clicked(mouse.ButtonMiddle, bounds) {
+			closeTab = i
+		}
+		if cw := w.ContextualOpen(0, image.Point{}, bounds, nil); cw != nil {
+			cw.Row(20).Dynamic(1)
+			if tab.pinned {
+				if cw.MenuItem(label.TA("Unpin", "LC")) {
+					tab.pinned = false
+				}
+			} else {
+				if cw.MenuItem(label.TA("Pin", "LC")) {
+					tab.pinned = true
+					if i != listingPanel.curTab {
+						listingNavigate(&api.Location{File: tab.file, Line: tab.line})
+					}
+				}
+			}
+			if cw.MenuItem(label.TA("Close", "LC")) {
+				closeTab = i
+			}
+		}
+	}
+
+	if closeTab >= 0 {
+		listingCloseTab(closeTab)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+)
+
+// Logpoints maps the ID of a breakpoint to its format string. Logpoints
+// are tracepoints that evaluate the expressions in their format string
+// (as Variables) and print the formatted message instead of the values.
+var Logpoints = map[int]string{}
+
+// parseLogpointFormat splits format into the literal parts and the
+// expressions enclosed in braces. Literal braces are written as {{ and }}.
+// The returned lits slice always has one more element than exprs.
+func parseLogpointFormat(format string) (lits []string, exprs []string, err error) {
+	var cur strings.Builder
+	for i := 0; i < len(format); i++ {
+		switch ch := format[i]; ch {
+		case '{':
+			if i+1 < len(format) && format[i+1] == '{' {
+				cur.WriteByte('{')
+				i++
+				continue
+			}
+			depth := 1
+			start := i + 1
+			for i++; i < len(format); i++ {
+				if format[i] == '{' {
+					depth++
+				} else if format[i] == '}' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if depth != 0 {
+				return nil, nil, errors.New("unterminated expression in logpoint format")
+			}
+			expr := strings.TrimSpace(format[start:i])
+			if expr == "" {
+				return nil, nil, errors.New("empty expression in logpoint format")
+			}
+			lits = append(lits, cur.String())
+			cur.Reset()
+			exprs = append(exprs, expr)
+		case '}':
+			if i+1 < len(format) && format[i+1] == '}' {
+				i++
+			}
+			cur.WriteByte('}')
+		default:
+			cur.WriteByte(ch)
+		}
+	}
+	lits = append(lits, cur.String())
+	return lits, exprs, nil
+}
+
+// formatLogpoint returns the message of a logpoint given the values of
+// the expressions in its format string.
+func formatLogpoint(format string, vals []api.Variable) string {
+	lits, exprs, err := parseLogpointFormat(format)
+	if err != nil {
+		return fmt.Sprintf("%s (%v)", format, err)
+	}
+	var buf strings.Builder
+	for i := range exprs {
+		buf.WriteString(lits[i])
+		switch {
+		case i >= len(vals):
+			buf.WriteString("<missing>")
+		case vals[i].Unreadable != "":
+			fmt.Fprintf(&buf, "<%s>", vals[i].Unreadable)
+		case vals[i].Kind == reflect.String:
+			buf.WriteString(vals[i].Value)
+		default:
+			buf.WriteString(wrapApiVariableSimple(&vals[i]).SinglelineString(false, false))
+		}
+	}
+	buf.WriteString(lits[len(lits)-1])
+	return buf.String()
+}
+
+// logpointBreakpoint fills bp so that it behaves as a logpoint for format.
+func logpointBreakpoint(bp *api.Breakpoint, format string) error {
+	_, exprs, err := parseLogpointFormat(format)
+	if err != nil {
+		return err
+	}
+	bp.Tracepoint = true
+	bp.Variables = exprs
+	return nil
+}
+
+// setLogpoint records that breakpoint id is a logpoint with the specified
+// format, or that it isn't a logpoint if format is empty.
+func setLogpoint(id int, format string) {
+	if format == "" {
+		delete(Logpoints, id)
+	} else {
+		Logpoints[id] = format
+	}
+	for i := range FrozenBreakpoints {
+		if FrozenBreakpoints[i].Bp.ID == id {
+			FrozenBreakpoints[i].Logpoint = format
+			saveConfiguration()
+			break
+		}
+	}
+}
+
+func listingSetLogpoint(file string, line int, format string) {
+	out := editorWriter{true}
+	requestedBp := &api.Breakpoint{File: file, Line: line}
+	if err := logpointBreakpoint(requestedBp, format); err != nil {
+		fmt.Fprintf(&out, "Could not create logpoint: %v\n", err)
+		return
+	}
+	if bp := setBreakpointEx(&out, requestedBp); bp != nil {
+		setLogpoint(bp.ID, format)
+	}
+	refreshState(refreshToSameFrame, clearBreakpoint, nil)
+}
+
+type logpointEditor struct {
+	file   string
+	line   int
+	editor nucular.TextEditor
+}
+
+func openLogpointEditor(mw nucular.MasterWindow, file string, line int) {
+	ed := &logpointEditor{file: file, line: line}
+	ed.editor.Flags = nucular.EditClipboard | nucular.EditSelectable | nucular.EditSigEnter
+	ed.editor.Active = true
+	mw.PopupOpen(fmt.Sprintf("Logpoint at %s:%d", ShortenFilePath(file), line), dynamicPopupFlags, rect.Rect{100, 100, 500, 700}, true, ed.update)
+}
+
+func (ed *logpointEditor) update(w *nucular.Window) {
+	w.Row(20).Dynamic(1)
+	w.Label("Message (use {expr} to print the value of expr):", "LC")
+	w.Row(30).Dynamic(1)
+	ev := ed.editor.Edit(w)
+
+	w.Row(20).Static(0, 80, 80)
+	w.Spacing(1)
+	if w.ButtonText("Cancel") {
+		w.Close()
+	}
+	if w.ButtonText("OK") || ev&nucular.EditCommitted != 0 {
+		go listingSetLogpoint(ed.file, ed.line, string(ed.editor.Buffer))
+		w.Close()
+	}
+}
+
+// printLogpoint prints the message for a logpoint hit to the scrollback.
+// Must be called with the window lock held.
+func printLogpoint(th *api.Thread, format string) {
+	var vals []api.Variable
+	if th.BreakpointInfo != nil {
+		vals = th.BreakpointInfo.Variables
+	}
+	style := wnd.Style()
+	c := scrollbackAppend()
+	defer c.End()
+	c.Text(fmt.Sprintf("> [goroutine %d] ", th.GoroutineID))
+	writeLinkToLocation(c, style, th.File, th.Line, th.PC)
+	c.Text(fmt.Sprintf(" %s\n", formatLogpoint(format, vals)))
+}
@@ -119,6 +119,7 @@ func setupStyle() {
 	style.GroupWindow.FooterPadding.Y = 0
 	style.MenuWindow.FooterPadding.Y = 0
 	style.ContextualWindow.FooterPadding.Y = 0
+	setupSyntaxColors()
 	zeroWidth = nucular.FontWidth(style.Font, "0")
 	spaceWidth = nucular.FontWidth(style.Font, " ")
 
@@ -163,6 +164,7 @@ type listline struct {
 	pc           bool
 	bp           *api.Breakpoint
 	bpenabled    bool
+	spans        []syntaxSpan
 }
 
 var listingPanel struct {
@@ -184,6 +186,15 @@ var listingPanel struct {
 	disassHoverIdx      int
 	disassHoverClickIdx int
 	centerOnDisassHover bool
+
+	identLine, identCol int    // position of the identifier under the last right click
+	ident               string // identifier under the last right click
+
+	tabs          []listingTab
+	curTab        int
+	back, forward []api.Location // navigation history
+
+	inlineValues bool // show the values of local variables at the end of lines
 }
 
 var wnd nucular.MasterWindow
@@ -231,7 +242,7 @@ func guiUpdate(w *nucular.Window) {
 			conf.Scaling -= 0.1
 			setupStyle()
 
-		case (e.Modifiers == key.ModControl) && (e.Code == key.CodeF):
+		case (e.Modifiers == key.ModControl|key.ModShift) && (e.Code == key.CodeF):
 			mw.SetPerf(!mw.GetPerf())
 
 		case (e.Modifiers == 0) && (e.Code == key.CodeEscape):
@@ -250,6 +261,12 @@ func guiUpdate(w *nucular.Window) {
 				doCommand("next")
 			}
 
+		case (e.Modifiers == key.ModAlt) && (e.Code == key.CodeLeftArrow):
+			listingBack(false)
+
+		case (e.Modifiers == key.ModAlt|key.ModShift) && (e.Code == key.CodeRightArrow):
+			listingBack(true)
+
 		case (e.Modifiers == 0) && (e.Code == key.CodeF11):
 			fallthrough
 		case (e.Modifiers == key.ModAlt) && (e.Code == key.CodeDownArrow):
@@ -363,12 +380,23 @@ func updateCommandPanel(w *nucular.Window) {
 	w.LayoutReserveRow(commandLineHeight, 1)
 	commandToolbar(w)
 
+	for _, k := range w.Input().Keyboard.Keys {
+		if k.Modifiers == key.ModControl && k.Code == key.CodeF {
+			openScrollbackFind(w.Master())
+		}
+	}
+	if scrollbackFind.active {
+		updateScrollbackFind(w)
+	}
+
 	w.Row(0).Dynamic(1)
 	if c := scrollbackEditor.Widget(w, scrollbackClear); c != nil {
 		scrollbackClear = false
+		resetScrollbackTranscript()
 		c.Align(richtext.AlignLeftDumb)
 		if len(scrollbackPreInitWrite) > 0 {
 			c.Text(string(scrollbackPreInitWrite))
+			recordScrollback(scrollbackSpan{text: string(scrollbackPreInitWrite)})
 		}
 		c.End()
 		scrollbackEditor.Sel.S = int32(len(scrollbackPreInitWrite))
@@ -426,7 +454,9 @@ func updateCommandPanel(w *nucular.Window) {
 				historyShown = -1
 				showHistory = true
 			case k.Modifiers == 0 && k.Code == key.CodeDeleteBackspace && historySearch:
-				historyNeedle = historyNeedle[:len(historyNeedle)]
+				if len(historyNeedle) > 0 {
+					historyNeedle = historyNeedle[:len(historyNeedle)-1]
+				}
 			}
 		}
 		if historySearch && kbd.Text != "" && kbd.Text != "\n" {
@@ -460,7 +490,7 @@ func updateCommandPanel(w *nucular.Window) {
 		if scriptRunning {
 			fmt.Fprintf(&scrollbackOut, "a script is running\n")
 		} else if starlarkMode != nil {
-			cmdhistory = append(cmdhistory, cmd)
+			appendCmdHistory(cmd)
 			fmt.Fprintf(&scrollbackOut, "%s %s\n", p, cmd)
 			starlarkMode <- cmd
 		} else if canExecuteCmd(cmd) && !client.Running() {
@@ -472,7 +502,7 @@ func updateCommandPanel(w *nucular.Window) {
 					cmd = "help"
 				}
 			} else {
-				cmdhistory = append(cmdhistory, cmd)
+				appendCmdHistory(cmd)
 				fmt.Fprintf(&scrollbackOut, "%s %s\n", p, cmd)
 			}
 			historyShown = len(cmdhistory)
@@ -652,23 +682,32 @@ func refreshState(toframe refreshToFrame, clearKind clearKind, state *api.Debugg
 	case clearBreakpoint:
 		breakpointsPanel.asyncLoad.clear()
 		checkpointsPanel.asyncLoad.clear()
+		timelinePanel.asyncLoad.clear()
 	case clearFrameSwitch:
 		localsPanel.asyncLoad.clear()
+		memoryPanel.asyncLoad.clear()
 		listingPanel.pinnedLoc = nil
 	case clearGoroutineSwitch:
 		stackPanel.asyncLoad.clear()
 		localsPanel.asyncLoad.clear()
 		regsPanel.asyncLoad.clear()
+		memoryPanel.asyncLoad.clear()
 		listingPanel.pinnedLoc = nil
 	case clearStop:
 		localsPanel.asyncLoad.clear()
 		regsPanel.asyncLoad.clear()
+		goroutinesPanel.rotateOnStop = true
 		goroutinesPanel.asyncLoad.clear()
 		stackPanel.asyncLoad.clear()
 		threadsPanel.asyncLoad.clear()
 		globalsPanel.asyncLoad.clear()
 		breakpointsPanel.asyncLoad.clear()
 		checkpointsPanel.asyncLoad.clear()
+		memoryPanel.rotateOnStop = true
+		memoryPanel.asyncLoad.clear()
+		librariesPanel.asyncLoad.clear()
+		goroutineTreePanel.asyncLoad.clear()
+		timelinePanel.asyncLoad.clear()
 		listingPanel.pinnedLoc = nil
 		silenced = false
 
@@ -770,6 +809,13 @@ func refreshState(toframe refreshToFrame, clearKind clearKind, state *api.Debugg
 	disassemblyPanel.asyncLoad.clear()
 	disassemblyPanel.loc = *loc
 
+	if listingPanel.pinnedLoc == nil {
+		if tabloc := pinnedTabLocation(loc.File); tabloc != nil {
+			listingPanel.pinnedLoc = tabloc
+			loc = tabloc
+		}
+	}
+
 	if clearKind != clearBreakpoint {
 		loadListing(loc, failstate)
 	}
@@ -777,6 +823,9 @@ func refreshState(toframe refreshToFrame, clearKind clearKind, state *api.Debugg
 	applyBreakpoints(failstate)
 
 	wnd.Walk(func(title string, data interface{}, docked bool, splitSize int, rect rect.Rect) {
+		if dv, ok := data.(*detailViewer); ok {
+			data = &dv.asyncLoad
+		}
 		if asyncLoad, ok := data.(*asyncLoad); ok && asyncLoad != nil {
 			if title == "Details" && clearKind != clearNothing && clearKind != clearBreakpoint {
 				asyncLoad.clear()
@@ -789,6 +838,7 @@ func refreshState(toframe refreshToFrame, clearKind clearKind, state *api.Debugg
 
 func loadDisassembly(p *asyncLoad) {
 	listingPanel.text = nil
+	disassemblyFind.reset()
 	listingPanel.recenterDisassembly = true
 	listingPanel.disassHoverIdx = -1
 	listingPanel.disassHoverClickIdx = -1
@@ -818,6 +868,7 @@ func loadDisassembly(p *asyncLoad) {
 func loadListing(loc *api.Location, failstate func(string, error)) {
 	listingPanel.listing = listingPanel.listing[:0]
 	listingPanel.recenterListing = true
+	listingFind.reset()
 
 	listingPanel.stepIntoInfo.Filename = ""
 	listingPanel.stepIntoInfo.Lineno = -1
@@ -832,6 +883,7 @@ func loadListing(loc *api.Location, failstate func(string, error)) {
 
 	listingPanel.file = loc.File
 	listingPanel.abbrevFile = abbrevFileName(loc.File)
+	listingOpenTab(loc.File, loc.Line)
 
 	if loc.File == "<autogenerated>" {
 		return
@@ -858,21 +910,33 @@ func loadListing(loc *api.Location, failstate func(string, error)) {
 		lineno++
 		atpc := lineno == loc.Line && listingPanel.pinnedLoc == nil
 		linetext := expandTabs(buf.Text())
-		listingPanel.listing = append(listingPanel.listing, listline{"", lineno, linetext, buf.Text(), atpc, nil, false})
+		listingPanel.listing = append(listingPanel.listing, listline{"", lineno, linetext, buf.Text(), atpc, nil, false, nil})
+	}
+
+	if err := buf.Err(); err != nil {
+		failstate("(reading file)", err)
+		return
+	}
+
+	lines := make([]string, len(listingPanel.listing))
+	for i := range listingPanel.listing {
+		lines[i] = listingPanel.listing[i].textWithTabs
+	}
+	nsegments := 0 // text segments drawn separately by highlightedLabel
+	if spans := highlightSource(loc.File, lines); spans != nil {
+		for i := range listingPanel.listing {
+			listingPanel.listing[i].spans = expandSpans(lines[i], spans[i])
+			nsegments += 2*len(spans[i]) + 1
+		}
 	}
 
 	const maxFontCacheSize = 500000
-	sz := 4*len(listingPanel.listing) + len(listingPanel.listing)/2
+	sz := 4*len(listingPanel.listing) + len(listingPanel.listing)/2 + nsegments
 	if sz > maxFontCacheSize {
 		sz = maxFontCacheSize
 	}
 	nucular.ChangeFontWidthCache(sz)
 
-	if err := buf.Err(); err != nil {
-		failstate("(reading file)", err)
-		return
-	}
-
 	d := digits(len(listingPanel.listing))
 	if d < 3 {
 		d = 3
@@ -955,6 +1019,7 @@ Options must appear before the command and include:
 	-d <dir>			builds inside the specified directory instead of the current directory (for debug and test)
 	-tags <taglist>			list of tags to pass to 'go build'
 	-r [stdin|stdout|stderr:]path	redirects a standard file descriptor to a file, if none is specified stdin is implied
+	-session <name>			starts the session saved with 'session save <name>', must be the only option
 `)
 	os.Exit(1)
 }
@@ -981,6 +1046,20 @@ optionsLoop:
 			}
 			opts.tags = args[i]
 			i++
+		case "-session":
+			i++
+			if i >= len(args) {
+				usage("wrong number of arguments after -session")
+			}
+			s := conf.Sessions[args[i]]
+			if s == nil {
+				usage(fmt.Sprintf("unknown session %q", args[i]))
+			}
+			if i != 2 || len(args) > i+1 {
+				usage("-session can not be used with other arguments")
+			}
+			pendingSession = s
+			return parseOptions(append([]string{args[0]}, s.Args...))
 		case "-r":
 			i++
 			if i >= len(args) {
@@ -1013,6 +1092,7 @@ optionsLoop:
 
 	opts.cmd = args[i]
 	opts.cmdArgs = args[i+1:]
+	opts.args = args[1:]
 
 	opts.defaultBackend = true
 	const defaultBackend = "--backend=default"
@@ -1036,6 +1116,7 @@ type commandLineOptions struct {
 	buildDir       string
 	tags           string
 	redirects      [3]string
+	args           []string // command line arguments, without the program name
 }
 
 func main() {
@@ -1068,12 +1149,17 @@ func main() {
 
 	BackendServer = parseArguments()
 
-	if BackendServer.debugid != "" && conf.FrozenBreakpoints != nil && conf.DisabledBreakpoints != nil {
+	if BackendServer.debugid != "" && conf.FrozenBreakpoints != nil && conf.DisabledBreakpoints != nil && pendingSession == nil {
 		FrozenBreakpoints = append(FrozenBreakpoints[:0], conf.FrozenBreakpoints[BackendServer.debugid]...)
 		DisabledBreakpoints = append(DisabledBreakpoints[:0], conf.DisabledBreakpoints[BackendServer.debugid]...)
 	}
+	loadCmdHistory()
 
-	loadPanelDescrToplevel(conf.Layouts["default"].Layout)
+	if pendingSession != nil && pendingSession.Layout != "" {
+		loadPanelDescrToplevel(pendingSession.Layout)
+	} else {
+		loadPanelDescrToplevel(conf.Layouts["default"].Layout)
+	}
 
 	curThread = -1
 	curGid = -1
@@ -1100,6 +1186,9 @@ To change font size use Ctrl-plus/Ctrl-minus or the 'config zoom' command.
 	go BackendServer.Start()
 
 	wnd.OnClose(func() {
+		wnd.Lock()
+		flushCmdHistory()
+		wnd.Unlock()
 		BackendServer.Close()
 		os.Exit(0)
 	})
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+	nstyle "github.com/aarzilli/nucular/style"
+
+	"golang.org/x/mobile/event/mouse"
+)
+
+const (
+	memoryBytesPerRow = 16
+	memoryWordSize    = 8
+	memoryMaxLength   = 64 * 1024
+	memoryChunkSize   = 512 // delve refuses to read more than 1000 bytes at a time
+)
+
+var memoryPanel = struct {
+	asyncLoad asyncLoad
+	exprEd    nucular.TextEditor
+
+	expr   string
+	addr   uint64
+	length int
+	words  bool
+
+	mem          []byte
+	littleEndian bool
+	err          error
+
+	// memory at the same location during the previous stop, used to
+	// highlight changed bytes
+	oldmem       []byte
+	oldaddr      uint64
+	rotateOnStop bool
+
+	history []uint64
+}{
+	exprEd: nucular.TextEditor{Flags: nucular.EditSelectable | nucular.EditClipboard | nucular.EditSigEnter},
+	length: 256,
+}
+
+// memoryAddressOf evaluates expr and returns the address that should be
+// examined: numbers are used directly, pointers are dereferenced and any
+// other value is examined at its own address.
+func memoryAddressOf(expr string) (uint64, error) {
+	expr = strings.TrimSpace(expr)
+	if addr, err := strconv.ParseUint(expr, 0, 64); err == nil {
+		return addr, nil
+	}
+	v, err := client.EvalVariable(currentEvalScope(), expr, ShortLoadConfig)
+	if err != nil {
+		return 0, err
+	}
+	if v.Unreadable != "" {
+		return 0, fmt.Errorf("unreadable %s", v.Unreadable)
+	}
+	switch v.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(v.Children) > 0 {
+			return v.Children[0].Addr, nil
+		}
+	case reflect.Uintptr:
+		return strconv.ParseUint(v.Value, 0, 64)
+	}
+	if v.Addr == 0 {
+		return 0, fmt.Errorf("%s is not addressable", expr)
+	}
+	return v.Addr, nil
+}
+
+func loadMemory(p *asyncLoad) {
+	if memoryPanel.rotateOnStop {
+		memoryPanel.rotateOnStop = false
+		memoryPanel.oldmem = memoryPanel.mem
+		memoryPanel.oldaddr = memoryPanel.addr
+	}
+
+	memoryPanel.mem = nil
+	memoryPanel.err = nil
+
+	if memoryPanel.expr == "" {
+		p.done(nil)
+		return
+	}
+
+	// errors are reported inside the panel, instead of through p.done, so
+	// that the toolbar stays visible and the expression can be corrected.
+	addr, err := memoryAddressOf(memoryPanel.expr)
+	if err != nil {
+		memoryPanel.err = err
+		p.done(nil)
+		return
+	}
+	memoryPanel.addr = addr
+
+	mem := make([]byte, 0, memoryPanel.length)
+	for len(mem) < memoryPanel.length {
+		n := memoryPanel.length - len(mem)
+		if n > memoryChunkSize {
+			n = memoryChunkSize
+		}
+		chunk, littleEndian, err := client.ExamineMemory(addr+uint64(len(mem)), n)
+		if err != nil {
+			if len(mem) == 0 {
+				memoryPanel.err = err
+			}
+			break
+		}
+		memoryPanel.littleEndian = littleEndian
+		mem = append(mem, chunk...)
+		if len(chunk) < n {
+			break
+		}
+	}
+	memoryPanel.mem = mem
+	p.done(nil)
+}
+
+// setMemoryExpr changes the expression examined by the memory panel,
+// remembering the current one so that it can be returned to.
+func setMemoryExpr(expr string, pushHistory bool) {
+	if pushHistory && memoryPanel.expr != "" {
+		memoryPanel.history = append(memoryPanel.history, memoryPanel.addr)
+	}
+	memoryPanel.expr = expr
+	memoryPanel.exprEd.Buffer = []rune(expr)
+	memoryPanel.exprEd.Cursor = len(memoryPanel.exprEd.Buffer)
+	memoryPanel.oldmem = nil
+	go func() {
+		memoryPanel.asyncLoad.clear()
+		wnd.Changed()
+	}()
+}
+
+func memoryByteChanged(addr uint64, b byte) bool {
+	if addr < memoryPanel.oldaddr || addr >= memoryPanel.oldaddr+uint64(len(memoryPanel.oldmem)) {
+		return false
+	}
+	return memoryPanel.oldmem[addr-memoryPanel.oldaddr] != b
+}
+
+func memoryWord(mem []byte, littleEndian bool) uint64 {
+	if littleEndian {
+		return binary.LittleEndian.Uint64(mem)
+	}
+	return binary.BigEndian.Uint64(mem)
+}
+
+func updateMemory(container *nucular.Window) {
+	w := memoryPanel.asyncLoad.showRequest(container)
+	if w == nil {
+		return
+	}
+
+	memoryToolbar(w)
+
+	switch {
+	case memoryPanel.expr == "":
+		w.Row(varRowHeight).Dynamic(1)
+		w.Label("Enter an address or expression", "LC")
+		return
+	case memoryPanel.err != nil:
+		w.Row(varRowHeight).Dynamic(1)
+		w.Label(fmt.Sprintf("Error: %v", memoryPanel.err), "LC")
+		return
+	}
+
+	style := w.Master().Style()
+	changedColor := color.RGBA{0xd0, 0x00, 0x00, 0xd0}
+
+	d := hexdigits(memoryPanel.addr + uint64(len(memoryPanel.mem)))
+	if d < 8 {
+		d = 8
+	}
+
+	mem := memoryPanel.mem
+	for start := 0; start < len(mem); start += memoryBytesPerRow {
+		end := start + memoryBytesPerRow
+		if end > len(mem) {
+			end = len(mem)
+		}
+		rowaddr := memoryPanel.addr + uint64(start)
+
+		w.Row(varRowHeight).Static()
+		w.LayoutSetWidthScaled(zeroWidth*(d+2) + style.Text.Padding.X*2)
+		w.Label(fmt.Sprintf("%0*x", d, rowaddr), "LC")
+
+		w.LayoutSetWidthScaled(zeroWidth*(memoryBytesPerRow*3+1) + style.Text.Padding.X*2)
+		bounds, out := w.Custom(nstyle.WidgetStateInactive)
+		if out != nil {
+			bounds.X += style.Text.Padding.X
+			bounds.Y += style.Text.Padding.Y
+			if memoryPanel.words {
+				for i := start; i+memoryWordSize <= end; i += memoryWordSize {
+					r := bounds
+					r.X += zeroWidth * ((i - start) / memoryWordSize) * (memoryWordSize*2 + 2)
+					r.W = zeroWidth * memoryWordSize * 2
+					changed := false
+					for j := i; j < i+memoryWordSize; j++ {
+						if memoryByteChanged(memoryPanel.addr+uint64(j), mem[j]) {
+							changed = true
+						}
+					}
+					if changed {
+						out.FillRect(r, 0, changedColor)
+					}
+					ptr := memoryWord(mem[i:i+memoryWordSize], memoryPanel.littleEndian)
+					c := style.Text.Color
+					if ptr != 0 {
+						c = linkColor
+						if w.Input().Mouse.HoveringRect(r) {
+							c = linkHoverColor
+						}
+						if !client.Running() && w.Input().Mouse.IsClickInRect(mouse.ButtonLeft, r) {
+							setMemoryExpr(fmt.Sprintf("%#x", ptr), true)
+						}
+					}
+					out.DrawText(r, fmt.Sprintf("%0*x", memoryWordSize*2, ptr), style.Font, c)
+				}
+			} else {
+				for i := start; i < end; i++ {
+					r := bounds
+					r.X += zeroWidth * (i - start) * 3
+					if i-start >= memoryBytesPerRow/2 {
+						r.X += zeroWidth
+					}
+					r.W = zeroWidth * 2
+					if memoryByteChanged(memoryPanel.addr+uint64(i), mem[i]) {
+						out.FillRect(r, 0, changedColor)
+					}
+					out.DrawText(r, fmt.Sprintf("%02x", mem[i]), style.Font, style.Text.Color)
+				}
+			}
+		}
+
+		w.LayoutSetWidthScaled(zeroWidth*(memoryBytesPerRow+2) + style.Text.Padding.X*2)
+		w.Label(memoryASCII(mem[start:end]), "LC")
+	}
+
+	if len(mem) < memoryPanel.length && len(mem) > 0 {
+		w.Row(varRowHeight).Dynamic(1)
+		w.Label(fmt.Sprintf("could not read memory past %#x", memoryPanel.addr+uint64(len(mem))), "LC")
+	} else if memoryPanel.length < memoryMaxLength {
+		w.Row(varRowHeight).Static(moreBtnWidth)
+		if w.ButtonText("More...") {
+			memoryResize(memoryPanel.length * 2)
+		}
+	}
+}
+
+func memoryToolbar(w *nucular.Window) {
+	w.MenubarBegin()
+	w.Row(varRowHeight).Static(90, 0, 40, 40, 40, 150, 100)
+	w.Label("Address:", "LC")
+	if ev := memoryPanel.exprEd.Edit(w); ev&nucular.EditCommitted != 0 {
+		setMemoryExpr(string(memoryPanel.exprEd.Buffer), true)
+	}
+	if w.ButtonText("<") && memoryPanel.expr != "" {
+		addr := uint64(0)
+		if memoryPanel.addr > uint64(memoryPanel.length) {
+			addr = memoryPanel.addr - uint64(memoryPanel.length)
+		}
+		setMemoryExpr(fmt.Sprintf("%#x", addr), false)
+	}
+	if w.ButtonText(">") && memoryPanel.expr != "" {
+		setMemoryExpr(fmt.Sprintf("%#x", memoryPanel.addr+uint64(memoryPanel.length)), false)
+	}
+	if w.ButtonText("Back") && len(memoryPanel.history) > 0 {
+		addr := memoryPanel.history[len(memoryPanel.history)-1]
+		memoryPanel.history = memoryPanel.history[:len(memoryPanel.history)-1]
+		setMemoryExpr(fmt.Sprintf("%#x", addr), false)
+	}
+	length := memoryPanel.length
+	if w.PropertyInt("Length:", memoryBytesPerRow, &length, memoryMaxLength, memoryBytesPerRow, memoryBytesPerRow) {
+		memoryResize(length)
+	}
+	w.CheckboxText("Pointers", &memoryPanel.words)
+	w.MenubarEnd()
+}
+
+func memoryResize(length int) {
+	if length > memoryMaxLength {
+		length = memoryMaxLength
+	}
+	length = (length + memoryBytesPerRow - 1) / memoryBytesPerRow * memoryBytesPerRow
+	memoryPanel.length = length
+	go func() {
+		memoryPanel.asyncLoad.clear()
+		wnd.Changed()
+	}()
+}
+
+func memoryASCII(mem []byte) string {
+	buf := make([]byte, len(mem))
+	for i := range mem {
+		if mem[i] >= 0x20 && mem[i] <= 0x7e {
+			buf[i] = mem[i]
+		} else {
+			buf[i] = '.'
+		}
+	}
+	return string(buf)
+}
+
+// openMemoryWindow shows the memory panel examining expr.
+func openMemoryWindow(expr string) {
+	setMemoryExpr(expr, true)
+	openWindow(infoMemory)
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
 	"github.com/aarzilli/gdlv/internal/prettyprint"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
 )
 
 func TestShortenType(t *testing.T) {
@@ -64,3 +73,385 @@ func TestWrapInstruction(t *testing.T) {
 	c("rex.w blah", "rex.w blah", "")
 	c("rex.w blah arg1", "rex.w blah", "arg1")
 }
+
+func TestHitCond(t *testing.T) {
+	for kind := range hitCondKinds {
+		hitCond, perG := formatHitCond(kind, 3)
+		gotkind, gotn := parseHitCond(&api.Breakpoint{HitCond: hitCond, HitCondPerG: perG})
+		if kind == hitCondNone {
+			if hitCond != "" || gotkind != hitCondNone {
+				t.Errorf("for kind %d got %q %d", kind, hitCond, gotkind)
+			}
+			continue
+		}
+		if gotkind != kind || gotn != 3 {
+			t.Errorf("for kind %d (%q perG=%v) got kind %d n %d", kind, hitCond, perG, gotkind, gotn)
+		}
+	}
+
+	if kind, _ := parseHitCond(&api.Breakpoint{HitCond: "!= 2"}); kind != hitCondNone {
+		t.Errorf("unexpected kind %d for unrepresentable condition", kind)
+	}
+}
+
+func TestParseLogpointFormat(t *testing.T) {
+	c := func(format string, tgtlits, tgtexprs []string) {
+		lits, exprs, err := parseLogpointFormat(format)
+		if err != nil {
+			t.Errorf("for %q unexpected error %v", format, err)
+			return
+		}
+		if fmt.Sprintf("%q", lits) != fmt.Sprintf("%q", tgtlits) || fmt.Sprintf("%q", exprs) != fmt.Sprintf("%q", tgtexprs) {
+			t.Errorf("for %q expected %q %q got %q %q", format, tgtlits, tgtexprs, lits, exprs)
+		}
+	}
+
+	c("no expressions", []string{"no expressions"}, nil)
+	c("req id={req.ID} user={u.Name}", []string{"req id=", " user=", ""}, []string{"req.ID", "u.Name"})
+	c("{{literal}} {x}", []string{"{literal} ", ""}, []string{"x"})
+	c("{m[T{1}]}", []string{"", ""}, []string{"m[T{1}]"})
+
+	for _, bad := range []string{"{unterminated", "empty {}"} {
+		if _, _, err := parseLogpointFormat(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestPortableBreakpoint(t *testing.T) {
+	fbp := frozenBreakpoint{
+		Bp:             api.Breakpoint{ID: 3, Name: "bp", FunctionName: "main.main", File: "/src/main.go", Line: 12, Cond: "i == 2", Variables: []string{"i"}},
+		LineInFunction: 2,
+		LineContents:   "\tfmt.Println(i)",
+		Group:          "g",
+	}
+	pbp := fbp.portable(true)
+	if !pbp.Disabled || pbp.Function != "main.main" || pbp.LineOffset != 2 || pbp.Group != "g" {
+		t.Errorf("wrong portable breakpoint %#v", pbp)
+	}
+	fbp2 := pbp.frozen()
+	if fbp2.Bp.FunctionName != fbp.Bp.FunctionName || fbp2.Bp.Cond != fbp.Bp.Cond || fbp2.LineInFunction != fbp.LineInFunction || fbp2.LineContents != fbp.LineContents || fbp2.Bp.Name != fbp.Bp.Name {
+		t.Errorf("round trip mismatch %#v", fbp2)
+	}
+}
+
+func TestRREventNumber(t *testing.T) {
+	for _, tc := range []struct {
+		when  string
+		event int64
+		ok    bool
+	}{
+		{"1234", 1234, true},
+		{"Current event: 42", 42, true},
+		{"", 0, false},
+		{"unknown", 0, false},
+	} {
+		event, ok := rrEventNumber(tc.when)
+		if event != tc.event || ok != tc.ok {
+			t.Errorf("rrEventNumber(%q) = %d, %v, expected %d, %v", tc.when, event, ok, tc.event, tc.ok)
+		}
+	}
+}
+
+func TestScrollbackSearch(t *testing.T) {
+	const text = "Foo bar foo BAR foo"
+	for _, tc := range []struct {
+		needle   string
+		from     int
+		backward bool
+		tgt      int
+	}{
+		{"foo", 0, false, 0},
+		{"foo", 1, false, 8},
+		{"foo", 17, false, 0},
+		{"Foo", 1, false, 0},
+		{"bar", 5, false, 12},
+		{"foo", 16, true, 8},
+		{"foo", 0, true, 16},
+		{"BAR", 19, true, 12},
+		{"baz", 0, false, -1},
+		{"", 0, false, -1},
+	} {
+		if got := scrollbackSearch(text, tc.needle, tc.from, tc.backward); got != tc.tgt {
+			t.Errorf("scrollbackSearch(%q, %d, %v) = %d, expected %d", tc.needle, tc.from, tc.backward, got, tc.tgt)
+		}
+	}
+}
+
+func TestFindLine(t *testing.T) {
+	lines := []string{"package main", "func main() {", "\tfmt.Println(\"Main\")", "}"}
+	line := func(i int) string { return lines[i] }
+	for _, tc := range []struct {
+		needle   string
+		from     int
+		backward bool
+		tgt      int
+	}{
+		{"main", 0, false, 0},
+		{"main", 1, false, 1},
+		{"main", 2, false, 2},
+		{"main", 3, false, 0},
+		{"Main", 0, false, 2},
+		{"main", 0, true, 0},
+		{"main", -1, true, 2},
+		{"}", 0, true, 3},
+		{"nothing", 0, false, -1},
+	} {
+		if got := findLine(len(lines), line, func(s string) bool { return findMatch(s, tc.needle) }, tc.from, tc.backward); got != tc.tgt {
+			t.Errorf("findLine(%q, %d, %v) = %d, expected %d", tc.needle, tc.from, tc.backward, got, tc.tgt)
+		}
+	}
+}
+
+func TestPushLocation(t *testing.T) {
+	var v []api.Location
+	v = pushLocation(v, api.Location{File: "a.go", Line: 1})
+	v = pushLocation(v, api.Location{File: "a.go", Line: 1})
+	v = pushLocation(v, api.Location{File: "b.go", Line: 2})
+	if len(v) != 2 || v[0].File != "a.go" || v[1].File != "b.go" {
+		t.Errorf("wrong history %v", v)
+	}
+	for i := 0; i < maxListingHistory+10; i++ {
+		v = pushLocation(v, api.Location{File: "c.go", Line: i})
+	}
+	if len(v) != maxListingHistory || v[len(v)-1].Line != maxListingHistory+9 {
+		t.Errorf("wrong history length %d (last %v)", len(v), v[len(v)-1])
+	}
+}
+
+func TestIdentAt(t *testing.T) {
+	const src = `package main
+
+import "fmt"
+
+func main() {
+	x := 1
+	fmt.Println(x)
+}
+`
+	var fset token.FileSet
+	f, err := parser.ParseFile(&fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		line, col int
+		name      string
+		sel       bool
+	}{
+		{6, 2, "x", false},
+		{7, 2, "fmt", false},
+		{7, 7, "Println", true},
+		{7, 14, "x", false},
+		{7, 5, "", false},
+	} {
+		ident, sel := identAt(&fset, f, tc.line, tc.col)
+		name := ""
+		if ident != nil {
+			name = ident.Name
+		}
+		if name != tc.name || (sel != nil) != tc.sel {
+			t.Errorf("identAt(%d, %d) = %q %v, expected %q %v", tc.line, tc.col, name, sel != nil, tc.name, tc.sel)
+		}
+		if got := identNameAt(strings.Split(src, "\n")[tc.line-1], tc.col); got != tc.name {
+			t.Errorf("identNameAt(%d, %d) = %q, expected %q", tc.line, tc.col, got, tc.name)
+		}
+	}
+}
+
+func TestHoverExpression(t *testing.T) {
+	const src = `package main
+
+func f(req *http.Request, s []int) {
+	req.Header.Get("a")
+	s[0].x = 1
+}
+`
+	var fset token.FileSet
+	f, err := parser.ParseFile(&fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		line, col int
+		expr      string
+	}{
+		{4, 2, "req"},
+		{4, 6, "req.Header"},
+		{4, 13, "req.Header.Get"},
+		{5, 2, "s"},
+		{5, 7, ""},
+	} {
+		ident, sel := identAt(&fset, f, tc.line, tc.col)
+		if ident == nil {
+			t.Fatalf("%d:%d: no identifier", tc.line, tc.col)
+		}
+		if expr := hoverExpression(&fset, []byte(src), ident, sel); expr != tc.expr {
+			t.Errorf("%d:%d: got %q expected %q", tc.line, tc.col, expr, tc.expr)
+		}
+	}
+}
+
+func TestInlineValueNames(t *testing.T) {
+	const src = `package main
+
+var global int
+
+func f(a int, s []int) {
+	b := a + global
+	s[b] = len(s)
+	go func(c int) {
+		b = c
+	}(b)
+	a = b
+}
+`
+	var fset token.FileSet
+	f, err := parser.ParseFile(&fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := inlineValueNames(&fset, f, 11)
+	for line, tgt := range map[int]string{5: "a s", 6: "b a", 7: "s b", 8: "", 9: "", 10: "b", 11: "a b"} {
+		if out := strings.Join(names[line], " "); out != tgt {
+			t.Errorf("line %d: got %q expected %q", line, out, tgt)
+		}
+	}
+	names = inlineValueNames(&fset, f, 9)
+	if out := strings.Join(names[8], " ") + "/" + strings.Join(names[9], " "); out != "c/b c" {
+		t.Errorf("closure: got %q", out)
+	}
+}
+
+func TestHighlightSource(t *testing.T) {
+	spanText := func(lines []string, spans [][]syntaxSpan) string {
+		var out []string
+		for i := range spans {
+			for _, span := range spans[i] {
+				out = append(out, fmt.Sprintf("%d:%s:%s", i+1, syntaxClassNames[span.class], lines[i][span.start:span.end]))
+			}
+		}
+		return strings.Join(out, " ")
+	}
+	for _, tc := range []struct {
+		file  string
+		lines []string
+		tgt   string
+	}{
+		{"a.go", []string{"func f() int {", "\treturn len(`a", "b`) + 1 /* x", "y */ // z", "}"},
+			"1:Keyword:func 1:Builtin:int 2:Keyword:return 2:Builtin:len 2:String:`a 3:String:b` 3:Number:1 3:Comment:/* x 4:Comment:y */ 4:Comment:// z"},
+		{"a.s", []string{"#include \"textflag.h\"", "TEXT ·f(SB),NOSPLIT,$0-8 // c", "loop:\tMOVQ $-1, AX; RET"},
+			"1:Directive:#include \"textflag.h\" 2:Keyword:TEXT 2:Builtin:SB 2:Number:$0-8 2:Comment:// c 3:Keyword:MOVQ 3:Number:$-1 3:Keyword:RET"},
+		{"a.c", []string{"#define X 1", "static int f(char *s) {", "\treturn s[0] == 'a'; /* a", "b */", "}"},
+			"1:Directive:#define X 1 2:Keyword:static 2:Builtin:int 2:Builtin:char 3:Keyword:return 3:Number:0 3:String:'a' 3:Comment:/* a 4:Comment:b */"},
+		{"a.txt", []string{"func"}, ""},
+	} {
+		if out := spanText(tc.lines, highlightSource(tc.file, tc.lines)); out != tc.tgt {
+			t.Errorf("%s:\ngot:      %s\nexpected: %s", tc.file, out, tc.tgt)
+		}
+	}
+
+	if out := expandSpans("\tx", []syntaxSpan{{1, 2, syntaxKeyword}}); out[0].start != 8 || out[0].end != 9 {
+		t.Errorf("expandSpans: %v", out)
+	}
+}
+
+func TestSessionDetailWindows(t *testing.T) {
+	dv := &detailViewer{}
+	dv.exprEd.Buffer = []rune("s.buf")
+	walk := func(fn nucular.WindowWalkFn) {
+		fn("Listing", &listingFind, true, 0, rect.Rect{})
+		fn("Variables", &localsPanel.asyncLoad, true, 0, rect.Rect{})
+		fn("Details", dv, false, 0, rect.Rect{})
+	}
+	if out := strings.Join(sessionDetailWindows(walk), ","); out != "s.buf" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestWatchAddrExpr(t *testing.T) {
+	// the same expression is used by watch and rev-watch
+	for _, typ := range []string{"int", "net/http.Request", "github.com/x/y.T", "[]net/http.Header", "*github.com/x/y.T", "map[string]*net/http.Cookie"} {
+		expr := watchAddrExpr(typ, 0xc000010000)
+		e, err := parser.ParseExpr(expr)
+		if err != nil {
+			t.Errorf("%s: could not parse %q: %v", typ, expr, err)
+			continue
+		}
+		deref, ok := e.(*ast.StarExpr)
+		if !ok {
+			t.Errorf("%s: %q is not a dereference", typ, expr)
+			continue
+		}
+		conv, ok := deref.X.(*ast.CallExpr)
+		if !ok || len(conv.Args) != 1 {
+			t.Errorf("%s: %q is not a conversion", typ, expr)
+			continue
+		}
+		ptr, ok := conv.Fun.(*ast.ParenExpr).X.(*ast.StarExpr)
+		lit, ok2 := ptr.X.(*ast.BasicLit)
+		if !ok || !ok2 {
+			t.Errorf("%s: %q does not convert to a quoted pointer type", typ, expr)
+			continue
+		}
+		if s, _ := strconv.Unquote(lit.Value); s != typ {
+			t.Errorf("%s: %q converts to %q", typ, expr, s)
+		}
+	}
+}
+
+func TestImportPathDir(t *testing.T) {
+	for _, tc := range []struct {
+		dir, importPath string
+		tgt             bool
+	}{
+		{"/usr/local/go/src/net/http", "net/http", true},
+		{"/home/user/go/src/github.com/x/y", "github.com/x/y", true},
+		{"/home/user/go/pkg/mod/github.com/x/y@v1.2.3", "github.com/x/y", true},
+		{"/home/user/go/pkg/mod/github.com/x/y@v1.2.3/z", "github.com/x/y/z", true},
+		{"/home/user/go/pkg/mod/github.com/!burnt!sushi/toml@v0.3.1", "github.com/BurntSushi/toml", true},
+		{"/home/user/go/pkg/mod/github.com/x/y@v1.2.3/z", "github.com/x/y", false},
+		{"/usr/local/go/src/net/http/httptest", "net/http", false},
+	} {
+		if out := importPathDir(tc.dir, tc.importPath); out != tc.tgt {
+			t.Errorf("importPathDir(%q, %q) = %v, expected %v", tc.dir, tc.importPath, out, tc.tgt)
+		}
+	}
+}
+
+func TestFuncScopeLines(t *testing.T) {
+	const src = `package main
+
+func f(a int) {
+	b := a
+	go func(c int) {
+		b = c
+	}(b)
+	a = b
+}
+
+func g(a int) {
+}
+`
+	var fset token.FileSet
+	f, err := parser.ParseFile(&fset, "test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(line int, tgt []int) {
+		t.Helper()
+		lines := funcScopeLines(&fset, enclosingFunc(&fset, f, line))
+		out := []int{}
+		for l := 1; l <= 12; l++ {
+			if lines[l] {
+				out = append(out, l)
+			}
+		}
+		if fmt.Sprint(out) != fmt.Sprint(tgt) {
+			t.Errorf("scope of line %d: expected %v got %v", line, tgt, out)
+		}
+	}
+	check(4, []int{3, 4, 5, 7, 8, 9})
+	check(6, []int{5, 6, 7})
+	check(11, []int{11, 12})
+}
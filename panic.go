@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+	"github.com/aarzilli/gdlv/internal/prettyprint"
+)
+
+// Names of the breakpoints created when conf.StopOnPanic is set.
+const (
+	panicBpName     = "gdlvPanic"
+	recoveredBpName = "gdlvRecovered"
+	throwBpName     = "gdlvThrow"
+)
+
+// IDs of the breakpoints delve sets on unrecovered panics and fatal errors.
+const (
+	unrecoveredPanicID = -1
+	fatalThrowID       = -2
+)
+
+const panicStackDepth = 50
+
+// applyStopOnPanic creates or removes the panic catchpoints so that they
+// match conf.StopOnPanic.
+func applyStopOnPanic(out io.Writer) {
+	if client == nil || curThread < 0 {
+		return
+	}
+	bps, err := client.ListBreakpoints()
+	if err != nil {
+		fmt.Fprintf(out, "Could not list breakpoints: %v\n", err)
+		return
+	}
+	existing := make(map[string]*api.Breakpoint)
+	hasThrow := false
+	for _, bp := range bps {
+		switch {
+		case isPanicCatchpoint(bp):
+			existing[bp.Name] = bp
+		case bp.ID == fatalThrowID:
+			hasThrow = true
+		}
+	}
+
+	if !conf.StopOnPanic {
+		for _, bp := range existing {
+			client.ClearBreakpoint(bp.ID)
+		}
+		return
+	}
+
+	create := func(name string, tracepoint bool, fns ...string) {
+		if existing[name] != nil {
+			return
+		}
+		var err error
+		for _, fn := range fns {
+			_, err = client.CreateBreakpoint(&api.Breakpoint{Name: name, FunctionName: fn, Line: -1, Tracepoint: tracepoint})
+			if err == nil {
+				return
+			}
+		}
+		fmt.Fprintf(out, "Could not set %s catchpoint: %v\n", name, err)
+	}
+
+	create(panicBpName, false, "runtime.gopanic")
+	create(recoveredBpName, true, "runtime.recovery")
+	if !hasThrow {
+		create(throwBpName, false, "runtime.fatalthrow", "runtime.throw")
+	}
+}
+
+// isPanicCatchpoint returns true if bp was created by applyStopOnPanic,
+// those breakpoints are managed by the configuration window and are not
+// saved with the other breakpoints.
+func isPanicCatchpoint(bp *api.Breakpoint) bool {
+	return bp.Name == panicBpName || bp.Name == recoveredBpName || bp.Name == throwBpName
+}
+
+// isRecoveredTracepoint returns true if bp is the tracepoint recording
+// recovered panics.
+func isRecoveredTracepoint(bp *api.Breakpoint) bool {
+	return bp != nil && bp.Name == recoveredBpName
+}
+
+// printPanicInfo prints the panic value or the fatal error message and the
+// stack of the goroutine if th is stopped at a panic catchpoint.
+func printPanicInfo(th *api.Thread) {
+	if th == nil || th.Breakpoint == nil {
+		return
+	}
+
+	var what string
+	var frame int
+	var expr string
+
+	switch {
+	case th.Breakpoint.Name == panicBpName:
+		what = "Panic (it could still be recovered)"
+		expr = "e"
+	case th.Breakpoint.ID == unrecoveredPanicID:
+		what = "Unrecovered panic"
+		expr = "msgs.arg"
+	case th.Breakpoint.ID == fatalThrowID || th.Breakpoint.Name == throwBpName:
+		what = "Fatal error"
+		expr = "s"
+		frame = -1
+	default:
+		return
+	}
+
+	frames, err := client.Stacktrace(th.GoroutineID, panicStackDepth, 0, nil)
+	if err != nil {
+		frames = nil
+	}
+
+	if frame < 0 {
+		// the message is an argument of runtime.throw (or runtime.fatal),
+		// which calls fatalthrow, possibly through systemstack.
+		frame = 0
+		for i := range frames {
+			if name := frames[i].Function.Name(); name == "runtime.throw" || name == "runtime.fatal" {
+				frame = i
+				break
+			}
+		}
+	}
+
+	value := ""
+	v, err := client.EvalVariable(api.EvalScope{GoroutineID: th.GoroutineID, Frame: frame}, expr, LongLoadConfig)
+	if err != nil {
+		value = fmt.Sprintf("<could not read value: %v>", err)
+	} else {
+		value = prettyprint.Multiline(v, "\t")
+	}
+
+	wnd.Lock()
+	defer wnd.Unlock()
+	style := wnd.Style()
+	c := scrollbackAppend()
+	defer c.End()
+	c.Text(fmt.Sprintf("%s in goroutine %d: %s\n", what, th.GoroutineID, value))
+	for i := range frames {
+		c.Text(fmt.Sprintf("\t%s()\n\t\t", frames[i].Function.Name()))
+		writeLinkToLocation(c, style, frames[i].File, frames[i].Line, frames[i].PC)
+		c.Text("\n")
+	}
+}
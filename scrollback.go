@@ -1,9 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
+	"github.com/aarzilli/nucular"
 	"github.com/aarzilli/nucular/richtext"
+
+	"golang.org/x/mobile/event/key"
 )
 
 var silenced bool
@@ -42,7 +55,7 @@ func (w *editorWriter) Write(b []byte) (int, error) {
 	}
 	scrollbackMu.Unlock()
 
-	c := scrollbackEditor.Append(true)
+	c := scrollbackAppend()
 	c.Text(string(b))
 	c.End()
 	scrollbackEditor.Tail(10000)
@@ -57,3 +70,227 @@ func currentColumn(buf []rune) int {
 	}
 	return len(buf)
 }
+
+// scrollbackSpan is a piece of text appended to the scrollback, for links
+// to source code file and line are the destination of the link.
+type scrollbackSpan struct {
+	text string
+	file string
+	line int
+}
+
+// scrollbackTranscript is a copy of the text appended to the scrollback,
+// used to export it. Must be accessed with the window lock held.
+var scrollbackTranscript []scrollbackSpan
+var scrollbackTranscriptLen int
+
+// Maximum size of scrollbackTranscript, older spans are discarded.
+const scrollbackTranscriptMax = 4 * 1024 * 1024
+
+// scrollbackCtor appends to scrollbackEditor, recording the text in
+// scrollbackTranscript.
+type scrollbackCtor struct {
+	*richtext.Ctor
+}
+
+// scrollbackAppend starts appending to the scrollback. Must be called with
+// the window lock held.
+func scrollbackAppend() scrollbackCtor {
+	return scrollbackCtor{scrollbackEditor.Append(true)}
+}
+
+func (c scrollbackCtor) Text(text string) {
+	c.Ctor.Text(text)
+	recordScrollback(scrollbackSpan{text: text})
+}
+
+func (c scrollbackCtor) link(text string, file string, line int, hoverColor color.RGBA, callback func()) {
+	c.Ctor.Link(text, hoverColor, callback)
+	recordScrollback(scrollbackSpan{text: text, file: file, line: line})
+}
+
+func recordScrollback(span scrollbackSpan) {
+	if span.text == "" {
+		return
+	}
+	scrollbackTranscript = append(scrollbackTranscript, span)
+	scrollbackTranscriptLen += len(span.text)
+	n := 0
+	for scrollbackTranscriptLen > scrollbackTranscriptMax && n < len(scrollbackTranscript)-1 {
+		scrollbackTranscriptLen -= len(scrollbackTranscript[n].text)
+		n++
+	}
+	if n > 0 {
+		scrollbackTranscript = append(scrollbackTranscript[:0], scrollbackTranscript[n:]...)
+	}
+}
+
+func resetScrollbackTranscript() {
+	scrollbackTranscript = scrollbackTranscript[:0]
+	scrollbackTranscriptLen = 0
+}
+
+// scrollbackText returns the text currently displayed in the scrollback.
+// Must be called with the window lock held.
+func scrollbackText() string {
+	if len(scrollbackTranscript) == 0 {
+		// scrollbackEditor is empty, Get can not be called
+		return ""
+	}
+	// Get always returns the text in scrollbackEditor.Sel
+	sel := scrollbackEditor.Sel
+	scrollbackEditor.Sel = richtext.Sel{S: 0, E: math.MaxInt32}
+	s := scrollbackEditor.Get(scrollbackEditor.Sel)
+	scrollbackEditor.Sel = sel
+	return s
+}
+
+// saveScrollback writes the scrollback to path, as HTML if path has a .html
+// or .htm extension, as plain text otherwise.
+func saveScrollback(path string) error {
+	wnd.Lock()
+	spans := append([]scrollbackSpan(nil), scrollbackTranscript...)
+	wnd.Unlock()
+
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	w := bufio.NewWriter(fh)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		writeScrollbackHTML(w, spans)
+	default:
+		for _, span := range spans {
+			w.WriteString(span.text)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return fh.Close()
+}
+
+func writeScrollbackHTML(w io.Writer, spans []scrollbackSpan) {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>gdlv transcript</title>\n")
+	fmt.Fprintf(w, "<style>a { color: #%02x%02x%02x; }</style>\n", linkColor.R, linkColor.G, linkColor.B)
+	fmt.Fprintf(w, "</head>\n<body>\n<pre>")
+	for _, span := range spans {
+		if span.file != "" {
+			u := url.URL{Scheme: "file", Path: filepath.ToSlash(span.file), Fragment: fmt.Sprintf("L%d", span.line)}
+			fmt.Fprintf(w, "<a href=\"%s\" title=\"%s:%d\">%s</a>", html.EscapeString(u.String()), html.EscapeString(span.file), span.line, html.EscapeString(span.text))
+		} else {
+			io.WriteString(w, html.EscapeString(span.text))
+		}
+	}
+	fmt.Fprintf(w, "</pre>\n</body>\n</html>\n")
+}
+
+// scrollbackFind is the state of the find bar of the command panel.
+var scrollbackFind struct {
+	active bool
+	ed     nucular.TextEditor
+	needle string
+	failed bool
+}
+
+func openScrollbackFind(mw nucular.MasterWindow) {
+	scrollbackFind.active = true
+	scrollbackFind.ed.Flags = nucular.EditSelectable | nucular.EditSigEnter | nucular.EditClipboard
+	mw.ActivateEditor(&scrollbackFind.ed)
+}
+
+// scrollbackSearch returns the offset of the first occurrence of needle in
+// text starting at or after from, or if backward is set, the last
+// occurrence starting before from. The search wraps around. Like
+// richtext.Look the search is case insensitive unless needle contains upper
+// case characters.
+func scrollbackSearch(text, needle string, from int, backward bool) int {
+	if needle == "" {
+		return -1
+	}
+	if strings.ToLower(needle) == needle {
+		text = asciiLower(text)
+	}
+	if from < 0 {
+		from = 0
+	}
+	if from > len(text) {
+		from = len(text)
+	}
+	if backward {
+		if i := strings.LastIndex(text[:from], needle); i >= 0 {
+			return i
+		}
+		return strings.LastIndex(text, needle)
+	}
+	if i := strings.Index(text[from:], needle); i >= 0 {
+		return from + i
+	}
+	return strings.Index(text, needle)
+}
+
+// asciiLower is strings.ToLower for ASCII characters only, it preserves the
+// byte offsets of s.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i := range b {
+		if b[i] >= 'A' && b[i] <= 'Z' {
+			b[i] += 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// scrollbackFindNext selects the next (or previous) match of the find bar.
+// If incremental is set the current match is considered.
+func scrollbackFindNext(backward, incremental bool) {
+	from := int(scrollbackEditor.Sel.S)
+	if !incremental && !backward {
+		from++
+	}
+	i := scrollbackSearch(scrollbackText(), scrollbackFind.needle, from, backward)
+	scrollbackFind.failed = i < 0 && scrollbackFind.needle != ""
+	if i < 0 {
+		return
+	}
+	scrollbackEditor.Sel = richtext.Sel{S: int32(i), E: int32(i + len(scrollbackFind.needle))}
+	scrollbackEditor.FollowCursor()
+}
+
+func updateScrollbackFind(w *nucular.Window) {
+	w.Row(headerRow).Static(50, 250, 60, 60, 60, 100)
+	w.Label("Find:", "LC")
+	for _, k := range w.Input().Keyboard.Keys {
+		if k.Modifiers == 0 && k.Code == key.CodeEscape {
+			scrollbackFind.active = false
+		}
+	}
+	ev := scrollbackFind.ed.Edit(w)
+	if needle := string(scrollbackFind.ed.Buffer); needle != scrollbackFind.needle {
+		scrollbackFind.needle = needle
+		scrollbackFindNext(false, true)
+	}
+	if ev&nucular.EditCommitted != 0 {
+		scrollbackFindNext(false, false)
+		w.Master().ActivateEditor(&scrollbackFind.ed)
+	}
+	if w.ButtonText("Next") {
+		scrollbackFindNext(false, false)
+	}
+	if w.ButtonText("Prev") {
+		scrollbackFindNext(true, false)
+	}
+	if w.ButtonText("Close") {
+		scrollbackFind.active = false
+	}
+	if scrollbackFind.failed {
+		w.Label("not found", "LC")
+	}
+	if !scrollbackFind.active {
+		w.Master().ActivateEditor(&commandLineEditor)
+	}
+}
@@ -41,6 +41,8 @@ type ServerDescr struct {
 	// connection to delve failed
 	connectionFailed bool
 	debugid          string
+	// command line arguments of gdlv, without -session, used to save sessions
+	args []string
 }
 
 var RemoveExecutable bool = true
@@ -80,6 +82,7 @@ func parseArguments() (descr ServerDescr) {
 	}
 
 	opts := parseOptions(os.Args)
+	descr.args = opts.args
 
 	optflags := []string{"-gcflags", "-N -l"}
 	ver, _ := goversion.Installed()
@@ -486,6 +489,7 @@ func continueToRuntimeMain() {
 
 func finishRestart(out io.Writer, contToMain bool) {
 	loadProgramInfo(out)
+	applyStopOnPanic(out)
 
 	if len(ScheduledBreakpoints) > 0 {
 		refreshState(refreshToFrameZero, clearStop, nil)
@@ -496,6 +500,8 @@ func finishRestart(out io.Writer, contToMain bool) {
 		ScheduledBreakpoints = ScheduledBreakpoints[:0]
 	}
 
+	applyPendingSession(out)
+
 	if contToMain {
 		continueToRuntimeMain()
 	}
@@ -504,22 +510,28 @@ func finishRestart(out io.Writer, contToMain bool) {
 func loadProgramInfo(out io.Writer) {
 	fmt.Fprintf(out, "Loading program info...")
 
-	var err error
-	funcsPanel.slice, err = client.ListFunctions("")
+	funcs, err := client.ListFunctions("")
 	if err != nil {
 		fmt.Fprintf(out, "Could not list functions: %v\n", err)
 	}
 
-	sourcesPanel.slice, err = client.ListSources("")
+	sources, err := client.ListSources("")
 	if err != nil {
 		fmt.Fprintf(out, "Could not list sources: %v\n", err)
 	}
 
-	typesPanel.slice, err = client.ListTypes("")
+	types, err := client.ListTypes("")
 	if err != nil {
 		fmt.Fprintf(out, "Could not list types: %v\n", err)
 	}
 
+	images, _ := client.ListDynamicLibraries()
+
+	wnd.Lock()
+	funcsPanel.slice = funcs
+	sourcesPanel.slice = sources
+	typesPanel.slice = types
+
 	lastModExe = client.LastModified()
 
 	funcsPanel.id++
@@ -527,6 +539,12 @@ func loadProgramInfo(out io.Writer) {
 	sourcesPanel.id++
 
 	completeLocationSetup()
+	wnd.Unlock()
+
+	symbolLocations.mu.Lock()
+	symbolLocations.images = len(images)
+	symbolLocations.ranges = nil
+	symbolLocations.mu.Unlock()
 
 	fmt.Fprintf(out, "done\n")
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+)
+
+// Session is the saved state of a debugging session.
+type Session struct {
+	Args             []string // command line arguments, without the program name
+	Breakpoints      []portableBreakpoint
+	Expressions      []string
+	CustomFormatters map[string]*CustomFormatter
+	Layout           string
+	DetailWindows    []string // expressions shown in open detail windows
+}
+
+// pendingSession is the session specified with -session, it is applied
+// once the target is started.
+var pendingSession *Session
+
+// sessionArgs returns the command line used to start the target, if gdlv
+// was started with -session these are the arguments of the session.
+func sessionArgs() []string {
+	return BackendServer.args
+}
+
+func currentSession() *Session {
+	updateFrozenBreakpoints()
+
+	s := &Session{
+		Args:             sessionArgs(),
+		Breakpoints:      []portableBreakpoint{},
+		CustomFormatters: make(map[string]*CustomFormatter),
+	}
+	for i := range FrozenBreakpoints {
+		s.Breakpoints = append(s.Breakpoints, FrozenBreakpoints[i].portable(false))
+	}
+	for i := range DisabledBreakpoints {
+		s.Breakpoints = append(s.Breakpoints, DisabledBreakpoints[i].portable(true))
+	}
+
+	wnd.Lock()
+	defer wnd.Unlock()
+	for i := range localsPanel.expressions {
+		s.Expressions = append(s.Expressions, localsPanel.expressions[i].Expr)
+	}
+	for k, cfmt := range conf.CustomFormatters {
+		s.CustomFormatters[k] = cfmt
+	}
+	s.Layout = serializeLayout()
+	s.DetailWindows = sessionDetailWindows(wnd.Walk)
+	return s
+}
+
+// sessionDetailWindows returns the expressions of the detail windows
+// visited by walk.
+func sessionDetailWindows(walk func(nucular.WindowWalkFn)) []string {
+	r := []string{}
+	walk(func(title string, data interface{}, docked bool, size int, rect rect.Rect) {
+		if dv, ok := data.(*detailViewer); ok {
+			r = append(r, string(dv.exprEd.Buffer))
+		}
+	})
+	return r
+}
+
+// applySession restores breakpoints, expressions, formatters and windows
+// of s. The command line can only be restored by starting gdlv with
+// -session.
+func applySession(out io.Writer, s *Session) {
+	clearAllBreakpoints(out)
+	for i := range s.Breakpoints {
+		importPortableBreakpoint(out, &s.Breakpoints[i])
+	}
+	saveConfiguration()
+
+	wnd.Lock()
+	for k, cfmt := range s.CustomFormatters {
+		conf.CustomFormatters[k] = cfmt
+	}
+	localsPanel.expressions = localsPanel.expressions[:0]
+	localsPanel.v = localsPanel.v[:0]
+	for _, expr := range s.Expressions {
+		addExpression(expr)
+	}
+	wnd.Unlock()
+
+	if s.Layout != "" {
+		loadPanelDescrToplevel(s.Layout)
+	}
+	for _, expr := range s.DetailWindows {
+		newDetailViewer(wnd, expr)
+	}
+
+	refreshState(refreshToSameFrame, clearBreakpoint, nil)
+}
+
+// applyPendingSession applies the session specified on the command line,
+// called after the target is started.
+func applyPendingSession(out io.Writer) {
+	if pendingSession == nil {
+		return
+	}
+	s := pendingSession
+	pendingSession = nil
+	applySession(out, s)
+}
+
+func sessionCommand(out io.Writer, args string) error {
+	argv := strings.Fields(args)
+	if len(argv) == 0 || argv[0] == "list" {
+		names := make([]string, 0, len(conf.Sessions))
+		for name := range conf.Sessions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "%s\t%s\n", name, strings.Join(conf.Sessions[name].Args, " "))
+		}
+		return nil
+	}
+	if len(argv) != 2 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	switch argv[0] {
+	case "save":
+		if conf.Sessions == nil {
+			conf.Sessions = make(map[string]*Session)
+		}
+		conf.Sessions[argv[1]] = currentSession()
+		saveConfiguration()
+		fmt.Fprintf(out, "Session %s saved\n", argv[1])
+	case "load":
+		s := conf.Sessions[argv[1]]
+		if s == nil {
+			return fmt.Errorf("unknown session %q", argv[1])
+		}
+		if curThread < 0 {
+			return fmt.Errorf("no process")
+		}
+		applySession(out, s)
+		if strings.Join(s.Args, " ") != strings.Join(sessionArgs(), " ") {
+			fmt.Fprintf(out, "Session %s was started with 'gdlv %s', use 'gdlv -session %s' to restart it with the same command line\n", argv[1], strings.Join(s.Args, " "), argv[1])
+		}
+	case "delete":
+		if conf.Sessions[argv[1]] == nil {
+			return fmt.Errorf("unknown session %q", argv[1])
+		}
+		delete(conf.Sessions, argv[1])
+		saveConfiguration()
+	default:
+		return fmt.Errorf("unknown subcommand %q", argv[0])
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+
+	"golang.org/x/mobile/event/key"
+)
+
+// sourceFind is the state of the find bar of the Listing and Disassembly
+// panels.
+type sourceFind struct {
+	active   bool
+	gotoLine bool // the find bar is asking for a line number
+	ed       nucular.TextEditor
+	needle   string
+	cur      int  // index of the current match
+	recenter bool // the current match should be scrolled into view
+	failed   bool
+}
+
+var listingFind = sourceFind{cur: -1}
+var disassemblyFind = sourceFind{cur: -1}
+
+var findMatchColor = color.RGBA{0x80, 0x80, 0x00, 0x50}
+
+func (f *sourceFind) open(mw nucular.MasterWindow, gotoLine bool) {
+	f.active = true
+	f.gotoLine = gotoLine
+	f.failed = false
+	f.ed.Flags = nucular.EditSelectable | nucular.EditSigEnter | nucular.EditClipboard
+	if gotoLine {
+		f.ed.Buffer = f.ed.Buffer[:0]
+		f.ed.Cursor = 0
+	}
+	mw.ActivateEditor(&f.ed)
+}
+
+// keys opens or closes the find bar in response to the keyboard input of
+// w. If canGotoLine is set Ctrl+G opens the find bar in go to line mode.
+func (f *sourceFind) keys(w *nucular.Window, canGotoLine bool) {
+	for _, k := range w.Input().Keyboard.Keys {
+		switch {
+		case k.Modifiers == key.ModControl && k.Code == key.CodeF:
+			f.open(w.Master(), false)
+		case k.Modifiers == key.ModControl && k.Code == key.CodeG && canGotoLine:
+			f.open(w.Master(), true)
+		case k.Modifiers == 0 && k.Code == key.CodeEscape:
+			f.active = false
+		}
+	}
+}
+
+// matches returns true if s matches the needle of the find bar.
+func (f *sourceFind) matches(s string) bool {
+	return f.active && !f.gotoLine && findMatch(s, f.needle)
+}
+
+// reset forgets the current match, called when the contents of the panel
+// change.
+func (f *sourceFind) reset() {
+	f.cur = -1
+	f.recenter = false
+}
+
+// position returns the index of the current match or, if there isn't one,
+// the index of the current line.
+func (f *sourceFind) position(current int) int {
+	if f.cur >= 0 {
+		return f.cur
+	}
+	if current < 0 {
+		return 0
+	}
+	return current
+}
+
+// update draws the find bar. The searched text has n lines, line returns
+// the text of the i-th line and current is the index of the line where
+// the search starts if there is no current match. If the find bar is in go
+// to line mode lineno returns the index of the specified line number, or
+// -1.
+func (f *sourceFind) update(w *nucular.Window, n int, line func(i int) string, current int, lineno func(n int) int) {
+	if !f.active {
+		return
+	}
+	w.Row(headerRow).Static(50, 250, 60, 60, 60, 100)
+	if f.gotoLine {
+		w.Label("Line:", "LC")
+	} else {
+		w.Label("Find:", "LC")
+	}
+	ev := f.ed.Edit(w)
+	needle := string(f.ed.Buffer)
+
+	if f.gotoLine {
+		if ev&nucular.EditCommitted != 0 {
+			i := -1
+			if n, err := strconv.Atoi(strings.TrimSpace(needle)); err == nil {
+				i = lineno(n)
+			}
+			f.failed = i < 0
+			if i >= 0 {
+				f.cur, f.recenter = i, true
+				f.active = false
+			}
+		}
+	} else {
+		if needle != f.needle {
+			f.needle = needle
+			f.next(n, line, f.position(current), false)
+		}
+		if ev&nucular.EditCommitted != 0 {
+			f.next(n, line, f.position(current)+1, false)
+			w.Master().ActivateEditor(&f.ed)
+		}
+		if w.ButtonText("Next") {
+			f.next(n, line, f.position(current)+1, false)
+		}
+		if w.ButtonText("Prev") {
+			f.next(n, line, f.position(current)-1, true)
+		}
+	}
+	if w.ButtonText("Close") {
+		f.active = false
+	}
+	if f.failed {
+		if f.gotoLine {
+			w.Label("no such line", "LC")
+		} else {
+			w.Label("not found", "LC")
+		}
+	}
+	if !f.active {
+		w.Master().ActivateEditor(&commandLineEditor)
+	}
+}
+
+func (f *sourceFind) next(n int, line func(i int) string, from int, backward bool) {
+	i := findLine(n, line, func(s string) bool { return findMatch(s, f.needle) }, from, backward)
+	f.failed = i < 0 && f.needle != ""
+	if i >= 0 {
+		f.cur, f.recenter = i, true
+	}
+}
+
+// findMatch returns true if s contains needle. Like richtext.Look the
+// search is case insensitive unless needle contains upper case characters.
+func findMatch(s, needle string) bool {
+	if needle == "" {
+		return false
+	}
+	if strings.ToLower(needle) == needle {
+		s = strings.ToLower(s)
+	}
+	return strings.Contains(s, needle)
+}
+
+// findLine returns the index of the first of n lines, starting at from,
+// that satisfies match, or if backward is set the last one starting at or
+// before from. The search wraps around, returns -1 if no line matches.
+func findLine(n int, line func(i int) string, match func(string) bool, from int, backward bool) int {
+	if n <= 0 {
+		return -1
+	}
+	from = ((from % n) + n) % n
+	for k := 0; k < n; k++ {
+		i := from + k
+		if backward {
+			i = from - k + n
+		}
+		i %= n
+		if match(line(i)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func listingLine(i int) string {
+	return listingPanel.listing[i].text
+}
+
+func listingLineno(n int) int {
+	for i := range listingPanel.listing {
+		if listingPanel.listing[i].lineno == n {
+			return i
+		}
+	}
+	return -1
+}
+
+func disassemblyLine(i int) string {
+	return listingPanel.text[i].op + " " + listingPanel.text[i].args
+}
+
+// listSearch implements 'list /regex/', moving the find position of the
+// Listing panel (or the Disassembly panel, if there is no source code) to
+// the next line matching rx.
+func listSearch(out io.Writer, rx *regexp.Regexp) error {
+	wnd.Lock()
+	defer wnd.Unlock()
+	defer wnd.Changed()
+
+	f, n, line, current := &listingFind, len(listingPanel.listing), listingLine, listingCurrentLine()
+	if n == 0 {
+		f, n, line, current = &disassemblyFind, len(listingPanel.text), disassemblyLine, disassemblyCurrentLine()
+	}
+
+	from := f.position(current)
+	i := findLine(n, line, rx.MatchString, from+1, false)
+	if i < 0 {
+		return fmt.Errorf("no line matches %s", rx)
+	}
+	f.cur, f.recenter = i, true
+	if f == &listingFind {
+		fmt.Fprintf(out, "%s:%d: %s\n", ShortenFilePath(listingPanel.file), listingPanel.listing[i].lineno, strings.TrimSpace(listingPanel.listing[i].text))
+	} else {
+		fmt.Fprintf(out, "%#x: %s\n", listingPanel.text[i].Loc.PC, line(i))
+	}
+	return nil
+}
+
+// listingCurrentLine returns the index of the line of the listing
+// currently highlighted.
+func listingCurrentLine() int {
+	for i, line := range listingPanel.listing {
+		if line.pc || (listingPanel.pinnedLoc != nil && line.lineno == listingPanel.pinnedLoc.Line) {
+			return i
+		}
+	}
+	return -1
+}
+
+// disassemblyCurrentLine returns the index of the instruction currently
+// highlighted.
+func disassemblyCurrentLine() int {
+	for i, instr := range listingPanel.text {
+		if instr.AtPC || instr.Loc.PC == listingPanel.framePC {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseListRegex returns the regular expression of 'list /regex/', or nil if
+// args is a linespec.
+func parseListRegex(args string) (*regexp.Regexp, error) {
+	if len(args) < 2 || args[0] != '/' || args[len(args)-1] != '/' {
+		return nil, nil
+	}
+	return regexp.Compile(args[1 : len(args)-1])
+}
@@ -33,6 +33,11 @@ const (
 	infoCheckpoints     = "Checkpoints"
 	infoDeferredCalls   = "DeferredCalls"
 	infoAutoCheckpoints = "AutoCheckpoints"
+	infoMemory          = "Memory"
+	infoLibraries       = "Libraries"
+	infoGoroutineTree   = "GoroutineTree"
+	infoTrace           = "Trace"
+	infoTimeline        = "Timeline"
 )
 
 type infoPanel struct {
@@ -44,7 +49,7 @@ type infoPanel struct {
 var infoNameToPanel map[string]infoPanel
 
 var infoModes = []string{
-	infoCommand, infoListing, infoDisassembly, infoGoroutines, infoStacktrace, infoLocals, infoGlobal, infoBps, infoThreads, infoRegisters, infoSources, infoFuncs, infoTypes, infoCheckpoints, infoDeferredCalls, infoAutoCheckpoints,
+	infoCommand, infoListing, infoDisassembly, infoGoroutines, infoStacktrace, infoLocals, infoGlobal, infoBps, infoThreads, infoRegisters, infoSources, infoFuncs, infoTypes, infoCheckpoints, infoDeferredCalls, infoAutoCheckpoints, infoMemory, infoLibraries, infoGoroutineTree, infoTrace, infoTimeline,
 }
 
 var codeToInfoMode = map[byte]string{
@@ -64,6 +69,11 @@ var codeToInfoMode = map[byte]string{
 	'k': infoCheckpoints,
 	'd': infoDeferredCalls,
 	'A': infoAutoCheckpoints,
+	'm': infoMemory,
+	'i': infoLibraries,
+	'R': infoGoroutineTree,
+	'x': infoTrace,
+	'e': infoTimeline,
 }
 
 var infoModeToCode = map[string]byte{}
@@ -87,6 +97,11 @@ func init() {
 	infoNameToPanel[infoCheckpoints] = infoPanel{updateCheckpoints, 0, &checkpointsPanel.asyncLoad}
 	infoNameToPanel[infoDeferredCalls] = infoPanel{updateDeferredCalls, 0, &stackPanel.asyncLoad}
 	infoNameToPanel[infoAutoCheckpoints] = infoPanel{updateAutoCheckpoints, 0, &autoCheckpointsPanel.asyncLoad}
+	infoNameToPanel[infoMemory] = infoPanel{updateMemory, 0, &memoryPanel.asyncLoad}
+	infoNameToPanel[infoLibraries] = infoPanel{updateLibraries, nucular.WindowNoScrollbar, &librariesPanel.asyncLoad}
+	infoNameToPanel[infoGoroutineTree] = infoPanel{updateGoroutineTree, 0, &goroutineTreePanel.asyncLoad}
+	infoNameToPanel[infoTrace] = infoPanel{updateTrace, nucular.WindowNoScrollbar, nil}
+	infoNameToPanel[infoTimeline] = infoPanel{updateTimeline, nucular.WindowNoScrollbar, &timelinePanel.asyncLoad}
 
 	for k, v := range codeToInfoMode {
 		infoModeToCode[v] = k
@@ -257,12 +272,26 @@ func listingToolbar(sw *nucular.Window) {
 	if listingPanel.pinnedLoc != nil {
 		sw.LayoutSetWidth(200)
 		if sw.ButtonText("Back to current frame") {
-			listingPanel.pinnedLoc = nil
-			go refreshState(refreshToSameFrame, clearNothing, nil)
+			listingBackToFrame()
 		}
 		showfilename = false
 	}
 
+	if listingPanel.curTab >= 0 && listingPanel.curTab < len(listingPanel.tabs) {
+		tab := &listingPanel.tabs[listingPanel.curTab]
+		sw.LayoutSetWidth(60)
+		if tab.pinned {
+			if sw.ButtonText("Unpin") {
+				tab.pinned = false
+			}
+		} else if sw.ButtonText("Pin") {
+			tab.pinned = true
+		}
+	}
+
+	sw.LayoutSetWidth(120)
+	sw.CheckboxText("Inline values", &listingPanel.inlineValues)
+
 	if listingPanel.stale {
 		sw.LayoutSetWidth(400)
 		sw.LabelColored("Warning: listing may not match stale executable", "LC", color.RGBA{0xff, 0x00, 0x00, 0xff})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"strconv"
+	"strings"
 
 	nstyle "github.com/aarzilli/nucular/style"
 )
@@ -52,3 +53,16 @@ var redThemeTable = nstyle.ColorTable{
 	ColorScrollbarCursorActive: color.RGBA{75, 95, 105, 255},
 	ColorTabHeader:             color.RGBA{181, 45, 69, 255},
 }
+
+// parseColor parses a color in #rrggbb format.
+func parseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("malformed color %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("malformed color %q", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
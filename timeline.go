@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+	nstyle "github.com/aarzilli/nucular/style"
+
+	"golang.org/x/mobile/event/mouse"
+)
+
+type timelineMarkerKind uint8
+
+const (
+	timelineBreakpointHit timelineMarkerKind = iota
+	timelineAutoCheckpoint
+	timelineCheckpoint
+	timelineNumKinds
+)
+
+var timelineLaneNames = [timelineNumKinds]string{"Breakpoints", "Auto", "Checkpoints"}
+
+// timelineMarker is a checkpoint plotted on the timeline. Markers are
+// positioned using the rr event number of the checkpoint, the Counter of
+// autocheckpoints is only their relative order and can not be compared with
+// manual checkpoints.
+type timelineMarker struct {
+	kind        timelineMarkerKind
+	event       int64
+	checkpoint  int
+	goroutineID int
+	where       string
+	descr       string
+}
+
+var timelinePanel = struct {
+	asyncLoad asyncLoad
+	markers   []timelineMarker
+	min, max  int64
+	zoom      float64
+	center    float64 // center of the visible part of the timeline, as a fraction of its length
+}{
+	zoom:   1,
+	center: 0.5,
+}
+
+const (
+	timelineLaneHeight = 24
+	timelineLabelWidth = 100
+	timelineMaxZoom    = 1 << 20
+)
+
+var rrEventRx = regexp.MustCompile(`\d+`)
+
+// rrEventNumber returns the rr event number contained in the When field of a
+// checkpoint or debugger state.
+func rrEventNumber(when string) (int64, bool) {
+	m := rrEventRx.FindString(when)
+	if m == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(m, 10, 64)
+	return n, err == nil
+}
+
+func loadTimeline(p *asyncLoad) {
+	timelinePanel.markers = timelinePanel.markers[:0]
+	if !client.Recorded() {
+		p.done(fmt.Errorf("not a recording"))
+		return
+	}
+	cps, err := client.ListCheckpoints()
+	if err != nil {
+		p.done(err)
+		return
+	}
+	whens := make(map[int]string, len(cps))
+	for _, cp := range cps {
+		whens[cp.ID] = cp.When
+	}
+
+	auto := make(map[int]bool)
+	autoCheckpointsPanel.mu.Lock()
+	for _, check := range autoCheckpointsPanel.checkpoints {
+		auto[check.ID] = true
+		event, ok := rrEventNumber(whens[check.ID])
+		if !ok {
+			continue
+		}
+		m := timelineMarker{kind: timelineAutoCheckpoint, event: event, checkpoint: check.ID, goroutineID: check.GoroutineID, where: check.Where, descr: fmt.Sprintf("c%d,%s", check.ID, check.Where)}
+		if check.Breakpoint != nil {
+			m.kind = timelineBreakpointHit
+			m.descr = fmt.Sprintf("%s at %s", formatBreakpointName(check.Breakpoint, false), formatBreakpointLocation(check.Breakpoint))
+		}
+		timelinePanel.markers = append(timelinePanel.markers, m)
+	}
+	autoCheckpointsPanel.mu.Unlock()
+
+	for _, cp := range cps {
+		if auto[cp.ID] {
+			continue
+		}
+		event, ok := rrEventNumber(cp.When)
+		if !ok {
+			continue
+		}
+		timelinePanel.markers = append(timelinePanel.markers, timelineMarker{kind: timelineCheckpoint, event: event, checkpoint: cp.ID, goroutineID: -1, where: cp.Where, descr: fmt.Sprintf("c%d %s", cp.ID, cp.Where)})
+	}
+
+	for i, m := range timelinePanel.markers {
+		if i == 0 || m.event < timelinePanel.min {
+			timelinePanel.min = m.event
+		}
+		if i == 0 || m.event > timelinePanel.max {
+			timelinePanel.max = m.event
+		}
+	}
+
+	p.done(nil)
+}
+
+// timelineVisibleRange returns the range of events currently visible.
+func timelineVisibleRange() (lo, hi float64) {
+	min, max := float64(timelinePanel.min), float64(timelinePanel.max)
+	if max <= min {
+		return min - 1, min + 1
+	}
+	span := (max - min) / timelinePanel.zoom
+	lo = min + timelinePanel.center*(max-min) - span/2
+	if lo < min {
+		lo = min
+	}
+	if lo+span > max {
+		lo = max - span
+	}
+	return lo, lo + span
+}
+
+func timelineSetZoom(zoom float64) {
+	if zoom < 1 {
+		zoom = 1
+	}
+	if zoom > timelineMaxZoom {
+		zoom = timelineMaxZoom
+	}
+	timelinePanel.zoom = zoom
+}
+
+func timelineScroll(delta float64) {
+	timelinePanel.center += delta / timelinePanel.zoom
+	if timelinePanel.center < 0 {
+		timelinePanel.center = 0
+	}
+	if timelinePanel.center > 1 {
+		timelinePanel.center = 1
+	}
+}
+
+func timelineMarkerColor(style *nstyle.Style, kind timelineMarkerKind) color.RGBA {
+	switch kind {
+	case timelineBreakpointHit:
+		return color.RGBA{0xdd, 0x33, 0x33, 0xff}
+	case timelineCheckpoint:
+		return linkColor
+	default:
+		return style.Text.Color
+	}
+}
+
+func updateTimeline(container *nucular.Window) {
+	w := timelinePanel.asyncLoad.showRequest(container)
+	if w == nil {
+		return
+	}
+	style := w.Master().Style()
+
+	lo, hi := timelineVisibleRange()
+
+	w.MenubarBegin()
+	w.Row(20).Static(30, 30, 30, 30, 60, 0)
+	if w.ButtonText("<") {
+		timelineScroll(-0.25)
+	}
+	if w.ButtonText(">") {
+		timelineScroll(+0.25)
+	}
+	if w.ButtonText("+") {
+		timelineSetZoom(timelinePanel.zoom * 2)
+	}
+	if w.ButtonText("-") {
+		timelineSetZoom(timelinePanel.zoom / 2)
+	}
+	if w.ButtonText("Fit") {
+		timelinePanel.zoom = 1
+		timelinePanel.center = 0.5
+	}
+	w.Label(fmt.Sprintf("events %d - %d", int64(lo), int64(hi)), "LC")
+	w.MenubarEnd()
+
+	if len(timelinePanel.markers) == 0 {
+		w.Row(varRowHeight).Dynamic(1)
+		w.Label("No checkpoints, use the AutoCheckpoints window or the checkpoint command to create them", "LC")
+		return
+	}
+
+	w.Row(timelineLaneHeight*int(timelineNumKinds)).Static(timelineLabelWidth, 0)
+	if lw := w.GroupBegin("timeline-lanes", nucular.WindowNoScrollbar); lw != nil {
+		for _, name := range timelineLaneNames {
+			lw.Row(timelineLaneHeight).Dynamic(1)
+			lw.Label(name, "LC")
+		}
+		lw.GroupEnd()
+	}
+
+	bounds, out := w.Custom(nstyle.WidgetStateInactive)
+	if out == nil {
+		return
+	}
+
+	in := w.Input()
+	if in.Mouse.HoveringRect(bounds) && in.Mouse.ScrollDelta != 0 {
+		if in.Mouse.ScrollDelta > 0 {
+			timelineSetZoom(timelinePanel.zoom * 2)
+		} else {
+			timelineSetZoom(timelinePanel.zoom / 2)
+		}
+		in.Mouse.ScrollDelta = 0
+	}
+
+	laneh := bounds.H / int(timelineNumKinds)
+	for i := 0; i <= int(timelineNumKinds); i++ {
+		y := bounds.Y + i*laneh
+		out.StrokeLine(image.Point{bounds.X, y}, image.Point{bounds.X + bounds.W, y}, 1, style.NormalWindow.BorderColor)
+	}
+
+	markerw := int(4 * style.Scaling)
+	for i := range timelinePanel.markers {
+		m := &timelinePanel.markers[i]
+		if float64(m.event) < lo || float64(m.event) > hi {
+			continue
+		}
+		x := bounds.X + int((float64(m.event)-lo)/(hi-lo)*float64(bounds.W-markerw))
+		r := rect.Rect{X: x, Y: bounds.Y + int(m.kind)*laneh + 2, W: markerw, H: laneh - 4}
+		c := timelineMarkerColor(style, m.kind)
+		if in.Mouse.HoveringRect(r) {
+			c = linkHoverColor
+			w.Tooltip(fmt.Sprintf("event %d: %s", m.event, m.descr))
+		}
+		out.FillRect(r, 0, c)
+		if !client.Running() && in.Mouse.IsClickInRect(mouse.ButtonLeft, r) {
+			go execRestartCheckpoint(m.checkpoint, m.goroutineID, m.where)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/label"
+)
+
+// traceEntry is a recorded tracepoint hit.
+type traceEntry struct {
+	Time         time.Time    `json:"time"`
+	GoroutineID  int          `json:"goroutineID"`
+	BreakpointID int          `json:"breakpointID"`
+	Breakpoint   string       `json:"breakpoint"`
+	Function     string       `json:"function"`
+	File         string       `json:"file"`
+	Line         int          `json:"line"`
+	Return       bool         `json:"return,omitempty"`
+	Message      string       `json:"message,omitempty"`
+	Arguments    []traceValue `json:"arguments,omitempty"`
+	Locals       []traceValue `json:"locals,omitempty"`
+	Variables    []traceValue `json:"variables,omitempty"`
+	ReturnValues []traceValue `json:"returnValues,omitempty"`
+}
+
+type traceValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var tracePanel = struct {
+	entries   []traceEntry
+	bpFilter  string
+	gidEditor nucular.TextEditor
+	echo      bool
+	id        int
+}{
+	gidEditor: nucular.TextEditor{Flags: nucular.EditClipboard | nucular.EditSelectable},
+	echo:      true,
+}
+
+// recordTracepointHit adds th, which must be stopped at a tracepoint, to
+// the trace panel. Must be called with the window lock held.
+func recordTracepointHit(th *api.Thread) {
+	bp := th.Breakpoint
+	e := traceEntry{
+		Time:         time.Now(),
+		GoroutineID:  th.GoroutineID,
+		BreakpointID: bp.ID,
+		Breakpoint:   traceBreakpointName(bp),
+		Function:     th.Function.Name(),
+		File:         th.File,
+		Line:         th.Line,
+		Return:       bp.TraceReturn,
+		ReturnValues: traceValues(th.ReturnValues),
+	}
+	if bpi := th.BreakpointInfo; bpi != nil {
+		e.Arguments = traceValues(bpi.Arguments)
+		e.Locals = traceValues(bpi.Locals)
+		e.Variables = traceValues(bpi.Variables)
+	}
+	if format, ok := Logpoints[bp.ID]; ok {
+		var vals []api.Variable
+		if th.BreakpointInfo != nil {
+			vals = th.BreakpointInfo.Variables
+		}
+		e.Message = formatLogpoint(format, vals)
+	}
+	tracePanel.entries = append(tracePanel.entries, e)
+	tracePanel.id++
+}
+
+func traceBreakpointName(bp *api.Breakpoint) string {
+	if bp.Name != "" {
+		return bp.Name
+	}
+	return strconv.Itoa(bp.ID)
+}
+
+func traceValues(vars []api.Variable) []traceValue {
+	if len(vars) == 0 {
+		return nil
+	}
+	r := make([]traceValue, len(vars))
+	for i := range vars {
+		r[i] = traceValue{vars[i].Name, wrapApiVariableSimple(&vars[i]).SinglelineString(true, true)}
+	}
+	return r
+}
+
+func (e *traceEntry) valuesString() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	var vals []string
+	for _, vs := range [][]traceValue{e.Arguments, e.Locals, e.Variables, e.ReturnValues} {
+		for _, v := range vs {
+			vals = append(vals, fmt.Sprintf("%s = %s", v.Name, v.Value))
+		}
+	}
+	return strings.Join(vals, ", ")
+}
+
+func (e *traceEntry) String() string {
+	ret := ""
+	if e.Return {
+		ret = " (return)"
+	}
+	return fmt.Sprintf("%s goroutine %d [%s] %s%s at %s:%d %s", e.Time.Format("15:04:05.000"), e.GoroutineID, e.Breakpoint, e.Function, ret, ShortenFilePath(e.File), e.Line, e.valuesString())
+}
+
+// filteredTraceEntries returns the indexes of the entries of the trace
+// panel that match the current breakpoint and goroutine filters.
+func filteredTraceEntries() []int {
+	gid := -1
+	if s := strings.TrimSpace(string(tracePanel.gidEditor.Buffer)); s != "" {
+		if n, err := strconv.Atoi(s); err == nil {
+			gid = n
+		}
+	}
+	r := make([]int, 0, len(tracePanel.entries))
+	for i := range tracePanel.entries {
+		e := &tracePanel.entries[i]
+		if tracePanel.bpFilter != "" && e.Breakpoint != tracePanel.bpFilter {
+			continue
+		}
+		if gid >= 0 && e.GoroutineID != gid {
+			continue
+		}
+		r = append(r, i)
+	}
+	return r
+}
+
+func updateTrace(container *nucular.Window) {
+	bpnames := []string{"All breakpoints"}
+	seen := map[string]bool{}
+	for i := range tracePanel.entries {
+		name := tracePanel.entries[i].Breakpoint
+		if !seen[name] {
+			seen[name] = true
+			bpnames = append(bpnames, name)
+		}
+	}
+	sort.Strings(bpnames[1:])
+
+	container.MenubarBegin()
+	container.Row(20).Static(200, 100, 100, 180, 80)
+	cur := 0
+	for i := range bpnames {
+		if i > 0 && bpnames[i] == tracePanel.bpFilter {
+			cur = i
+		}
+	}
+	if newcur := container.ComboSimple(bpnames, cur, 20); newcur != cur {
+		if newcur == 0 {
+			tracePanel.bpFilter = ""
+		} else {
+			tracePanel.bpFilter = bpnames[newcur]
+		}
+	}
+	container.Label("Goroutine:", "RC")
+	tracePanel.gidEditor.Edit(container)
+	container.CheckboxText("Echo to scrollback", &tracePanel.echo)
+	if container.ButtonText("Clear") {
+		tracePanel.entries = nil
+		tracePanel.id++
+	}
+	container.MenubarEnd()
+
+	entries := filteredTraceEntries()
+
+	container.Row(0).Dynamic(1)
+	gl, w := nucular.GroupListStart(container, len(entries), "trace", 0)
+	if w == nil {
+		return
+	}
+	gl.SkipToVisible(varRowHeight)
+	for gl.Next() {
+		e := &tracePanel.entries[entries[gl.Index()]]
+		w.Row(varRowHeight).Static()
+		w.LayoutFitWidth(tracePanel.id, 100)
+		w.Label(e.String(), "LC")
+		if w := w.ContextualOpen(0, image.Point{}, w.LastWidgetBounds, nil); w != nil {
+			w.Row(20).Dynamic(1)
+			if w.MenuItem(label.TA("Show location", "LC")) {
+				listingNavigate(&api.Location{File: e.File, Line: e.Line})
+			}
+			if w.MenuItem(label.TA("Filter by breakpoint", "LC")) {
+				tracePanel.bpFilter = e.Breakpoint
+			}
+			if w.MenuItem(label.TA("Filter by goroutine", "LC")) {
+				tracePanel.gidEditor.Buffer = []rune(strconv.Itoa(e.GoroutineID))
+				tracePanel.gidEditor.Cursor = len(tracePanel.gidEditor.Buffer)
+			}
+		}
+	}
+}
+
+// exportTrace writes the entries of the trace panel matching the current
+// filters to path, as JSON if path ends in .json and as CSV otherwise.
+func exportTrace(path string) (int, error) {
+	wnd.Lock()
+	idxs := filteredTraceEntries()
+	entries := make([]traceEntry, len(idxs))
+	for i, idx := range idxs {
+		entries[i] = tracePanel.entries[idx]
+	}
+	wnd.Unlock()
+
+	fh, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer fh.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = writeTraceJSON(fh, entries)
+	} else {
+		err = writeTraceCSV(fh, entries)
+	}
+	return len(entries), err
+}
+
+func writeTraceJSON(out io.Writer, entries []traceEntry) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "\t")
+	return enc.Encode(entries)
+}
+
+func writeTraceCSV(out io.Writer, entries []traceEntry) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"time", "goroutine", "breakpoint", "function", "file", "line", "return", "values"})
+	for i := range entries {
+		e := &entries[i]
+		w.Write([]string{e.Time.Format(time.RFC3339Nano), strconv.Itoa(e.GoroutineID), e.Breakpoint, e.Function, e.File, strconv.Itoa(e.Line), strconv.FormatBool(e.Return), e.valuesString()})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func tracelogCommand(out io.Writer, args string) error {
+	argv := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	switch argv[0] {
+	case "clear":
+		wnd.Lock()
+		tracePanel.entries = nil
+		tracePanel.id++
+		wnd.Unlock()
+		wnd.Changed()
+	case "export":
+		if len(argv) < 2 || strings.TrimSpace(argv[1]) == "" {
+			return fmt.Errorf("not enough arguments")
+		}
+		path := expandTilde(strings.TrimSpace(argv[1]))
+		n, err := exportTrace(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Exported %d tracepoint hits to %s\n", n, path)
+	case "":
+		openWindow(infoTrace)
+	default:
+		return fmt.Errorf("unknown subcommand %q", argv[0])
+	}
+	return nil
+}
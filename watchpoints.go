@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aarzilli/gdlv/internal/dlvclient/service/api"
+)
+
+// watchpoint is a data watchpoint created with the watch command.
+type watchpoint struct {
+	expr     string
+	addrExpr string // expression evaluating to the watched memory, valid in any scope
+	old      string // value of the watched memory at the last stop
+}
+
+// Watchpoints maps the ID of a hardware watchpoint to its description.
+var Watchpoints = map[int]*watchpoint{}
+
+// Maximum number of instructions executed by the single stepping fallback
+// before giving up.
+const watchMaxSteps = 100000
+
+// watchStopRequested is set by the interrupt command to stop the single
+// stepping fallback, which would otherwise not be interruptible since the
+// target is stopped between steps.
+var watchStopRequested int32
+
+// watchStopped returns true, once, if the interrupt command was executed
+// since the last call.
+func watchStopped(out io.Writer) bool {
+	if atomic.SwapInt32(&watchStopRequested, 0) == 0 {
+		return false
+	}
+	fmt.Fprintf(out, "Interrupted\n")
+	return true
+}
+
+func watch(out io.Writer, args string) error {
+	expr := strings.TrimSpace(args)
+	if expr == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	if curThread < 0 {
+		return fmt.Errorf("no process")
+	}
+
+	scope := currentEvalScope()
+	wp, err := newWatchpoint(scope, expr)
+	if err != nil {
+		return err
+	}
+
+	bp, err := client.CreateWatchpoint(scope, expr, api.WatchWrite)
+	if err == nil && bp != nil {
+		Watchpoints[bp.ID] = wp
+		fmt.Fprintf(out, "Watchpoint %d set on %s\n", bp.ID, expr)
+		refreshState(refreshToSameFrame, clearBreakpoint, nil)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Hardware watchpoints not available (%v), single stepping until %s changes (Shift-F5 to stop)\n", err, expr)
+	return watchSingleStep(out, wp)
+}
+
+func newWatchpoint(scope api.EvalScope, expr string) (*watchpoint, error) {
+	v, err := client.EvalVariable(scope, expr, LongLoadConfig)
+	if err != nil {
+		return nil, err
+	}
+	if v.Addr == 0 {
+		return nil, fmt.Errorf("can not watch %s, it is not backed by memory", expr)
+	}
+	return &watchpoint{
+		expr:     expr,
+		addrExpr: watchAddrExpr(v.Type, v.Addr),
+		old:      wrapApiVariableSimple(v).SinglelineString(true, true),
+	}, nil
+}
+
+// watchAddrExpr returns an expression evaluating to the value of type typ
+// stored at addr. The type name is quoted because it can contain a package
+// path.
+func watchAddrExpr(typ string, addr uint64) string {
+	return fmt.Sprintf("*(*%q)(%#x)", typ, addr)
+}
+
+// eval returns the current value of the memory watched by wp.
+func (wp *watchpoint) eval() (string, error) {
+	v, err := client.EvalVariable(api.EvalScope{-1, 0, 0}, wp.addrExpr, LongLoadConfig)
+	if err != nil {
+		return "", err
+	}
+	return wrapApiVariableSimple(v).SinglelineString(true, true), nil
+}
+
+func (wp *watchpoint) printChange(out io.Writer, what, cur string) {
+	fmt.Fprintf(out, "%s %s changed\n\told value: %s\n\tnew value: %s\n", what, wp.expr, wp.old, cur)
+	wp.old = cur
+}
+
+// watchSingleStep steps the current thread one instruction at a time until
+// the memory watched by wp changes. Only writes made by the current thread
+// can be detected this way.
+func watchSingleStep(out io.Writer, wp *watchpoint) error {
+	var state *api.DebuggerState
+	defer func() {
+		refreshState(refreshToFrameZero, clearStop, state)
+	}()
+	atomic.StoreInt32(&watchStopRequested, 0)
+	for i := 0; i < watchMaxSteps; i++ {
+		if watchStopped(out) {
+			return nil
+		}
+		var err error
+		state, err = client.StepInstruction()
+		if err != nil {
+			return err
+		}
+		if state.Exited {
+			return nil
+		}
+		cur, err := wp.eval()
+		if err != nil {
+			printcontext(out, state)
+			return fmt.Errorf("could not read %s: %v", wp.expr, err)
+		}
+		if cur != wp.old {
+			printcontext(out, state)
+			wp.printChange(out, "Watched expression", cur)
+			return nil
+		}
+		if th := state.CurrentThread; th != nil && th.Breakpoint != nil {
+			printcontext(out, state)
+			return nil
+		}
+	}
+	fmt.Fprintf(out, "%s did not change after %d instructions\n", wp.expr, watchMaxSteps)
+	return nil
+}
+
+// printWatchpointHit prints the old and new values of the watched memory
+// if th is stopped at a watchpoint created by the watch command.
+func printWatchpointHit(out io.Writer, th *api.Thread) {
+	if th == nil || th.Breakpoint == nil {
+		return
+	}
+	wp := Watchpoints[th.Breakpoint.ID]
+	if wp == nil {
+		return
+	}
+	cur, err := wp.eval()
+	if err != nil {
+		fmt.Fprintf(out, "Watchpoint %d: could not read %s: %v\n", th.Breakpoint.ID, wp.expr, err)
+		return
+	}
+	wp.printChange(out, fmt.Sprintf("Watchpoint %d:", th.Breakpoint.ID), cur)
+}
+
+// revWatch runs a recording backwards until the value of expr changes,
+// stopping at the instruction that last wrote it.
+func revWatch(out io.Writer, args string) error {
+	expr := strings.TrimSpace(args)
+	if expr == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	if curThread < 0 {
+		return fmt.Errorf("no process")
+	}
+	if !client.Recorded() {
+		return fmt.Errorf("rev-watch can only be used on recordings")
+	}
+
+	scope := currentEvalScope()
+	wp, err := newWatchpoint(scope, expr)
+	if err != nil {
+		return err
+	}
+
+	var state *api.DebuggerState
+	defer func() {
+		refreshState(refreshToFrameZero, clearStop, state)
+	}()
+
+	bp, err := client.CreateWatchpoint(scope, expr, api.WatchWrite)
+	if err != nil || bp == nil {
+		fmt.Fprintf(out, "Hardware watchpoints not available (%v), single stepping backwards until %s changes (Shift-F5 to stop)\n", err, expr)
+		atomic.StoreInt32(&watchStopRequested, 0)
+		for i := 0; i < watchMaxSteps; i++ {
+			if watchStopped(out) {
+				return nil
+			}
+			state, err = client.ReverseStepInstruction()
+			if err != nil {
+				return err
+			}
+			if done, err := wp.revWatchCheck(out, state); done {
+				return err
+			}
+		}
+		fmt.Fprintf(out, "%s did not change in the last %d instructions\n", expr, watchMaxSteps)
+		return nil
+	}
+	defer client.ClearBreakpoint(bp.ID)
+
+	when := ""
+	for {
+		for state = range client.Rewind() {
+			if state.Err != nil {
+				return state.Err
+			}
+		}
+		if state == nil || state.Exited || state.When == when {
+			break
+		}
+		when = state.When
+		if done, err := wp.revWatchCheck(out, state); done {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Reached the start of the recording, %s was not written\n", expr)
+	return nil
+}
+
+// revWatchCheck returns true if rev-watch should stop at state because the
+// watched memory was last written by the instruction that follows it, or
+// because it can not be read.
+func (wp *watchpoint) revWatchCheck(out io.Writer, state *api.DebuggerState) (bool, error) {
+	cur, err := wp.eval()
+	if err != nil {
+		printcontext(out, state)
+		return true, fmt.Errorf("could not read %s: %v", wp.expr, err)
+	}
+	if cur == wp.old {
+		return false, nil
+	}
+	wp.printLastWrite(out, state, cur)
+	return true, nil
+}
+
+func (wp *watchpoint) printLastWrite(out io.Writer, state *api.DebuggerState, before string) {
+	printcontext(out, state)
+	fmt.Fprintf(out, "%s last written here\n\tvalue before: %s\n\tvalue after: %s\n", wp.expr, before, wp.old)
+}